@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+// cleanup removes everything discovery published about a node: VF resources, labels under
+// the prefix, PFLinkDownCondition and SriovNodeState if states client is set. It is safe to
+// run repeatedly, e.g. once discovery is uninstalled or the node is decommissioned.
+func cleanup(hostname, labelPrefix string, client kubernetes.Interface, states nodestate.Interface) error {
+	if err := doDiscovery(hostname, v1.ResourceList{}, v1.ResourceList{}, client); err != nil {
+		return err
+	}
+	if labelPrefix != "" {
+		if err := labelNode(hostname, labelPrefix, nil, client); err != nil {
+			return err
+		}
+	}
+	if err := removeLinkCondition(hostname, client); err != nil {
+		return err
+	}
+	if states == nil {
+		return nil
+	}
+	return deleteNodeState(hostname, states)
+}
+
+// linkConditionRemovalPatch returns strategic merge patch deleting PFLinkDownCondition
+// from node status, other conditions are left intact. Patch fails with a conflict if node
+// was changed since it was read.
+func linkConditionRemovalPatch(resourceVersion string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"resourceVersion": resourceVersion},
+		"status": map[string]interface{}{
+			"conditions": []map[string]interface{}{
+				{"type": nodestate.PFLinkDownCondition, "$patch": "delete"},
+			},
+		},
+	})
+}
+
+// removeLinkCondition deletes PFLinkDownCondition of a node if it is set. Node is read
+// again and patch is retried if it conflicts with a concurrent update.
+func removeLinkCondition(hostname string, client kubernetes.Interface) error {
+	err := retryOnConflict(func() error {
+		node, err := client.Core().Nodes().Get(hostname, meta_v1.GetOptions{})
+		if err != nil || !hasCondition(node, nodestate.PFLinkDownCondition) {
+			return err
+		}
+		patch, err := linkConditionRemovalPatch(node.ResourceVersion)
+		if err != nil {
+			return err
+		}
+		log.Printf("Removing condition %s of a node %s\n", nodestate.PFLinkDownCondition, hostname)
+		_, err = client.Core().Nodes().Patch(hostname, types.StrategicMergePatchType, patch, "status")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error removing condition of a node %s: %v", hostname, err)
+	}
+	return nil
+}
+
+func hasCondition(node *v1.Node, conditionType v1.NodeConditionType) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteNodeState deletes SriovNodeState of a node, a missing one is not an error.
+func deleteNodeState(hostname string, client nodestate.Interface) error {
+	log.Printf("Deleting SriovNodeState of a node %s\n", hostname)
+	if err := client.Delete(hostname); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error deleting SriovNodeState of a node %s: %v", hostname, err)
+	}
+	return nil
+}
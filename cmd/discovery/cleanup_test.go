@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+func makePublishedNode() *v1.Node {
+	node := makeTestNode()
+	for _, resources := range []v1.ResourceList{node.Status.Capacity, node.Status.Allocatable} {
+		resources[NetdevVFsResource] = resource.MustParse("3")
+		resources[DPDKVFsResource] = resource.MustParse("1")
+		resources[pfResource("ens2f0")] = resource.MustParse("4")
+	}
+	node.Labels["sriov.mirantis.com/ens2f0.driver"] = "i40e"
+	node.Labels["sriov.mirantis.com/pci-8086-1572"] = "true"
+	node.Status.Conditions = append(node.Status.Conditions, v1.NodeCondition{
+		Type: nodestate.PFLinkDownCondition, Status: v1.ConditionTrue, Reason: nodestate.AllPFLinksDownReason,
+	})
+	return node
+}
+
+func TestLinkConditionRemovalPatch(t *testing.T) {
+	patch, err := linkConditionRemovalPatch("7")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"metadata": {"resourceVersion": "7"},
+		"status": {"conditions": [{"type": "SriovPFLinkDown", "$patch": "delete"}]}}`, string(patch))
+}
+
+func TestCleanup(t *testing.T) {
+	node := makePublishedNode()
+	client, patches := fakeNodeClient(t, node)
+	states := nodestate.NewFake(nodestate.SriovNodeState{ObjectMeta: meta_v1.ObjectMeta{Name: "node-1"}})
+	require.NoError(t, cleanup("node-1", DefaultLabelPrefix, client, states))
+
+	require.Len(t, *patches, 3)
+	require.JSONEq(t, `{"metadata": {"resourceVersion": "7"}, "status": {
+		"capacity": {"totalvfs": null, "netdevvfs": null, "dpdkvfs": null, "totalvfs-ens2f0": null},
+		"allocatable": {"totalvfs": null, "netdevvfs": null, "dpdkvfs": null, "totalvfs-ens2f0": null}}}`,
+		string((*patches)[0].Patch))
+	require.JSONEq(t, `{"metadata": {
+		"labels": {"sriov.mirantis.com/ens2f0.driver": null, "sriov.mirantis.com/pci-8086-1572": null},
+		"resourceVersion": "7"}}`, string((*patches)[1].Patch))
+	require.JSONEq(t, `{"metadata": {"resourceVersion": "7"},
+		"status": {"conditions": [{"type": "SriovPFLinkDown", "$patch": "delete"}]}}`, string((*patches)[2].Patch))
+
+	expected := makeTestNode()
+	delete(expected.Status.Capacity, TotalVFsResource)
+	delete(expected.Status.Allocatable, TotalVFsResource)
+	require.Equal(t, expected.Status.Capacity, node.Status.Capacity)
+	require.Equal(t, expected.Status.Allocatable, node.Status.Allocatable)
+	require.Equal(t, expected.Labels, node.Labels)
+	require.Equal(t, expected.Status.Conditions, node.Status.Conditions)
+	_, err := states.Get("node-1")
+	require.True(t, errors.IsNotFound(err), "expected SriovNodeState to be deleted, got %v", err)
+
+	*patches = nil
+	require.NoError(t, cleanup("node-1", DefaultLabelPrefix, client, states))
+	require.Len(t, *patches, 1, "only resources should be patched once node is clean")
+	require.JSONEq(t, `{"metadata": {"resourceVersion": "7"}, "status": {"capacity": {}, "allocatable": {}}}`, string((*patches)[0].Patch))
+}
+
+func TestCleanupWithoutLabelsAndStates(t *testing.T) {
+	node := makePublishedNode()
+	client, patches := fakeNodeClient(t, node)
+	require.NoError(t, cleanup("node-1", "", client, nil))
+	require.Len(t, *patches, 2)
+	require.Equal(t, "i40e", node.Labels["sriov.mirantis.com/ens2f0.driver"])
+	require.False(t, hasCondition(node, nodestate.PFLinkDownCondition))
+}
+
+func TestRemoveLinkConditionRetriesConflicts(t *testing.T) {
+	defer func(interval time.Duration) {
+		nodePatchRetryInterval = interval
+	}(nodePatchRetryInterval)
+	nodePatchRetryInterval = 0
+	node := makePublishedNode()
+	conflict := errors.NewConflict(schema.GroupResource{Resource: "nodes"}, "node-1", fmt.Errorf("changed"))
+	client, patches := fakeNodeClient(t, node, conflict)
+	require.NoError(t, removeLinkCondition("node-1", client))
+	require.Len(t, *patches, 2)
+	require.False(t, hasCondition(node, nodestate.PFLinkDownCondition))
+
+	client, _ = fakeNodeClient(t, makePublishedNode(), errors.NewServerTimeout(schema.GroupResource{Resource: "nodes"}, "patch", 1))
+	require.Error(t, removeLinkCondition("node-1", client))
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const (
+	sriovVirtfnMask = "sys/class/net/%s/device/virtfn*"
+	virtfnPrefix    = "virtfn"
+	// iffUp is IFF_UP bit of interface flags
+	iffUp = 0x1
+
+	NetdevVFsResource v1.ResourceName = "netdevvfs"
+	DPDKVFsResource   v1.ResourceName = "dpdkvfs"
+)
+
+// dpdkDrivers are userspace drivers, VF bound to any of them doesn't have a kernel netdev.
+var dpdkDrivers = map[string]bool{
+	"vfio-pci":        true,
+	"igb_uio":         true,
+	"uio_pci_generic": true,
+}
+
+// driverMode returns mode of a VF bound to a given driver, netdev or dpdk.
+func driverMode(driver string) string {
+	if dpdkDrivers[driver] {
+		return "dpdk"
+	}
+	return "netdev"
+}
+
+// driverModeResource returns resource VF bound to a given driver should be reported as.
+func driverModeResource(driver string) v1.ResourceName {
+	return v1.ResourceName(driverMode(driver) + "vfs")
+}
+
+// vf is a virtual function of a PF.
+type vf struct {
+	// index is N of the virtfnN link
+	index      int
+	pciAddress string
+	// driver VF is bound to, empty if none
+	driver string
+	// netdev is a name of the VF interface if it is in the host network namespace
+	netdev string
+	// driverOverride is a driver VF was bound to with driver_override, e.g. by the policy
+	driverOverride string
+	// inUse is true if VF is claimed by the host, i.e. its interface is up in the host namespace
+	// or it is bound to a DPDK driver without driver_override, e.g. for a DPDK application
+	// of the host
+	inUse bool
+	// mac and vlan are configured by the PF driver, mac of the VF interface is used if PF doesn't report it
+	mac  string
+	vlan int
+}
+
+// usable returns true if VF can be given to a pod.
+func (v vf) usable() bool {
+	return v.driver != "" && !v.inUse
+}
+
+// discoverVFs lists VFs of a device by virtfn* links sorted by index. PCI address and driver
+// are names of directories virtfn* and virtfn*/driver links point to. Interface of a VF is
+// listed in virtfn*/net only while it is in the host network namespace, interfaces moved
+// to pods are not visible there.
+func discoverVFs(virtfnGlob string) ([]vf, error) {
+	virtfns, err := filepath.Glob(virtfnGlob)
+	if err != nil {
+		return nil, err
+	}
+	vfs := make([]vf, 0, len(virtfns))
+	for _, virtfn := range virtfns {
+		var v vf
+		if v.index, err = strconv.Atoi(strings.TrimPrefix(filepath.Base(virtfn), virtfnPrefix)); err != nil {
+			return nil, fmt.Errorf("unexpected VF link %s", virtfn)
+		}
+		if address, err := os.Readlink(virtfn); err == nil {
+			v.pciAddress = filepath.Base(address)
+		}
+		driver, err := os.Readlink(filepath.Join(virtfn, "driver"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			v.driver = filepath.Base(driver)
+		}
+		if v.driverOverride, err = readDriverOverride(virtfn); err != nil {
+			return nil, err
+		}
+		v.inUse = dpdkDrivers[v.driver] && v.driverOverride != v.driver
+		netdevs, err := filepath.Glob(filepath.Join(virtfn, "net", "*"))
+		if err != nil {
+			return nil, err
+		}
+		if len(netdevs) != 0 {
+			v.netdev = filepath.Base(netdevs[0])
+			if v.inUse, err = interfaceUp(netdevs[0]); err != nil {
+				return nil, err
+			}
+			if v.mac, err = interfaceAddress(netdevs[0]); err != nil {
+				return nil, err
+			}
+		}
+		vfs = append(vfs, v)
+	}
+	sort.Slice(vfs, func(i, j int) bool {
+		return vfs[i].index < vfs[j].index
+	})
+	return vfs, nil
+}
+
+// readDriverOverride reads driver_override of a VF, kernel reports "(null)" if it isn't set.
+func readDriverOverride(virtfn string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(virtfn, "driver_override"))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if override := strings.TrimSpace(string(data)); override != "(null)" {
+		return override, nil
+	}
+	return "", nil
+}
+
+// interfaceAddress reads MAC address of an interface, empty if unknown.
+func interfaceAddress(netdev string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(netdev, "address"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(data)), err
+}
+
+// interfaceUp reads flags of an interface and checks IFF_UP.
+func interfaceUp(netdev string) (bool, error) {
+	data, err := ioutil.ReadFile(filepath.Join(netdev, "flags"))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	flags, err := strconv.ParseUint(strings.TrimSpace(string(data)), 0, 32)
+	if err != nil {
+		return false, fmt.Errorf("error parsing flags of %s: %v", netdev, err)
+	}
+	return flags&iffUp != 0, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverVFs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sriov-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFakePF(t, dir, pf{name: "eth0", numvfs: 6},
+		vf{driver: "ixgbevf", netdev: "eth10", inUse: true},
+		vf{driver: "ixgbevf", netdev: "eth11"},
+		vf{driver: "ixgbevf"},
+		vf{driver: "vfio-pci", driverOverride: "vfio-pci"},
+		// bound to a DPDK driver by the host
+		vf{driver: "vfio-pci"},
+		vf{driverOverride: "vfio-pci"},
+	)
+
+	vfs, err := discoverVFs(fmt.Sprintf(filepath.Join(dir, sriovVirtfnMask), "eth0"))
+	require.NoError(t, err)
+	require.Equal(t, []vf{
+		{index: 0, driver: "ixgbevf", netdev: "eth10", inUse: true},
+		{index: 1, driver: "ixgbevf", netdev: "eth11"},
+		{index: 2, driver: "ixgbevf"},
+		{index: 3, driver: "vfio-pci", driverOverride: "vfio-pci"},
+		{index: 4, driver: "vfio-pci", inUse: true},
+		{index: 5, driverOverride: "vfio-pci"},
+	}, vfs)
+	usable := 0
+	for _, v := range vfs {
+		if v.usable() {
+			usable++
+		}
+	}
+	require.Equal(t, 3, usable)
+}
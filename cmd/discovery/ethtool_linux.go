@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// siocEthtool and ethtoolGDrvInfo are SIOCETHTOOL and ETHTOOL_GDRVINFO in linux/sockios.h and linux/ethtool.h.
+	siocEthtool     = 0x8946
+	ethtoolGDrvInfo = 0x3
+)
+
+// ethtoolDrvInfo is struct ethtool_drvinfo in linux/ethtool.h.
+type ethtoolDrvInfo struct {
+	cmd         uint32
+	driver      [32]byte
+	version     [32]byte
+	fwVersion   [32]byte
+	busInfo     [32]byte
+	eromVersion [32]byte
+	reserved2   [12]byte
+	nPrivFlags  uint32
+	nStats      uint32
+	testinfoLen uint32
+	eedumpLen   uint32
+	regdumpLen  uint32
+}
+
+// ifreq is struct ifreq in linux/if.h with a pointer in its union, padded to the size of the union.
+type ifreq struct {
+	name [syscall.IFNAMSIZ]byte
+	data uintptr
+	_    [16]byte
+}
+
+// readFirmwareVersion returns firmware version of a device as ethtool -i reports it, empty if unknown.
+// Drivers don't expose it in sysfs, so it is the only detail read from the host rather than --directory.
+var readFirmwareVersion = ethtoolFirmwareVersion
+
+func ethtoolFirmwareVersion(name string) string {
+	if len(name) >= syscall.IFNAMSIZ {
+		return ""
+	}
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return ""
+	}
+	defer syscall.Close(fd)
+	info := ethtoolDrvInfo{cmd: ethtoolGDrvInfo}
+	var req ifreq
+	copy(req.name[:], name)
+	req.data = uintptr(unsafe.Pointer(&info))
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), siocEthtool, uintptr(unsafe.Pointer(&req)))
+	runtime.KeepAlive(&info)
+	if errno != 0 {
+		return ""
+	}
+	return string(bytes.TrimRight(info.fwVersion[:], "\x00"))
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+// readFirmwareVersion returns firmware version of a device, it is unknown outside of linux.
+var readFirmwareVersion = func(name string) string {
+	return ""
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultLabelPrefix is a prefix of labels with details of PFs of a node.
+	DefaultLabelPrefix = "sriov.mirantis.com"
+
+	// maxLabelLength is the longest name or value of a label.
+	maxLabelLength = 63
+)
+
+// invalidLabelChars are replaced in values of labels, e.g. spaces in firmware versions.
+var invalidLabelChars = regexp.MustCompile("[^-A-Za-z0-9_.]")
+
+// validateLabelPrefix checks that prefix is a DNS subdomain, empty prefix disables labels.
+func validateLabelPrefix(prefix string) error {
+	if prefix == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(prefix); len(errs) != 0 {
+		return fmt.Errorf("invalid label prefix %q: %s", prefix, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// pfLabels returns labels with hardware details of every PF, e.g. sriov.mirantis.com/ens1f0.vendor=8086,
+// and a label for every PCI device ID present on the node, e.g. sriov.mirantis.com/pci-8086-1572=true,
+// so that pods can select nodes with a NIC model regardless of interface names.
+func pfLabels(prefix string, pfs []pf) map[string]string {
+	labels := map[string]string{}
+	for _, p := range pfs {
+		details := map[string]string{
+			"vendor":   p.vendor,
+			"device":   p.device,
+			"driver":   p.driver,
+			"firmware": p.firmware,
+		}
+		if p.speed > 0 {
+			details["speed"] = strconv.FormatInt(p.speed, 10)
+		}
+		for detail, value := range details {
+			if value = labelValue(value); value != "" {
+				labels[labelName(prefix, p.name+"."+detail)] = value
+			}
+		}
+		if p.vendor != "" && p.device != "" {
+			labels[labelName(prefix, "pci-"+p.vendor+"-"+p.device)] = "true"
+		}
+	}
+	return labels
+}
+
+// labelName truncates name to the longest allowed, name has to end with an alphanumeric character.
+func labelName(prefix, name string) string {
+	if len(name) > maxLabelLength {
+		name = strings.TrimRight(name[:maxLabelLength], "-_.")
+	}
+	return prefix + "/" + name
+}
+
+// labelValue replaces characters which are not allowed in labels, empty value is returned if none is left.
+func labelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(strings.TrimSpace(value), "_")
+	if len(value) > maxLabelLength {
+		value = value[:maxLabelLength]
+	}
+	return strings.Trim(value, "-_.")
+}
+
+// labelsPatch returns merge patch setting desired labels and removing other labels with the prefix,
+// nil if current labels are up to date. Patch fails with a conflict if node was changed since it was read.
+func labelsPatch(prefix, resourceVersion string, current, desired map[string]string) ([]byte, error) {
+	changes := map[string]interface{}{}
+	for name := range current {
+		if _, exists := desired[name]; !exists && strings.HasPrefix(name, prefix+"/") {
+			changes[name] = nil
+		}
+	}
+	for name, value := range desired {
+		if current[name] != value {
+			changes[name] = value
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":          changes,
+			"resourceVersion": resourceVersion,
+		},
+	})
+}
+
+// labelNode publishes PF details as labels of a node and removes stale ones, e.g. of a PF that
+// disappeared. Node is read again and patch is retried if it conflicts with a concurrent update.
+func labelNode(hostname, prefix string, pfs []pf, client kubernetes.Interface) error {
+	desired := pfLabels(prefix, pfs)
+	err := retryOnConflict(func() error {
+		node, err := client.Core().Nodes().Get(hostname, meta_v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		patch, err := labelsPatch(prefix, node.ResourceVersion, node.Labels, desired)
+		if err != nil || patch == nil {
+			return err
+		}
+		log.Printf("Patching labels of a node %s with %s\n", hostname, patch)
+		_, err = client.Core().Nodes().Patch(hostname, types.MergePatchType, patch)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error patching labels of a node %s: %v", hostname, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPFLabels(t *testing.T) {
+	labels := pfLabels("example.com", []pf{
+		{name: "ens1f0", vendor: "8086", device: "1572", driver: "i40e", firmware: "6.01 0x800034a4 1.1747.0", speed: 10000},
+		{name: "ens1f1", vendor: "8086", device: "1572", driver: "i40e"},
+	})
+	require.Equal(t, map[string]string{
+		"example.com/ens1f0.vendor":   "8086",
+		"example.com/ens1f0.device":   "1572",
+		"example.com/ens1f0.driver":   "i40e",
+		"example.com/ens1f0.firmware": "6.01_0x800034a4_1.1747.0",
+		"example.com/ens1f0.speed":    "10000",
+		"example.com/ens1f1.vendor":   "8086",
+		"example.com/ens1f1.device":   "1572",
+		"example.com/ens1f1.driver":   "i40e",
+		"example.com/pci-8086-1572":   "true",
+	}, labels)
+}
+
+func TestLabelNameTruncated(t *testing.T) {
+	name := strings.Repeat("a", maxLabelLength-1) + ".vendor"
+	require.Equal(t, "example.com/"+strings.Repeat("a", maxLabelLength-1), labelName("example.com", name))
+	require.Equal(t, "example.com/ens1f0.vendor", labelName("example.com", "ens1f0.vendor"))
+}
+
+func TestValidateLabelPrefix(t *testing.T) {
+	require.NoError(t, validateLabelPrefix(DefaultLabelPrefix))
+	require.NoError(t, validateLabelPrefix(""))
+	require.Error(t, validateLabelPrefix("Example.com/"))
+}
+
+func TestLabelNodeRemovesStaleLabels(t *testing.T) {
+	node := makeTestNode()
+	node.Labels["example.com/ens2f0.driver"] = "mlx5_core"
+	node.Labels["example.com/ens1f0.driver"] = "ixgbe"
+	node.Labels["other.com/ens2f0.driver"] = "mlx5_core"
+	client, patches := fakeNodeClient(t, node)
+	pfs := []pf{{name: "ens1f0", vendor: "8086", device: "1572", driver: "i40e"}}
+	require.NoError(t, labelNode("node-1", "example.com", pfs, client))
+
+	require.Len(t, *patches, 1)
+	require.JSONEq(t, `{"metadata": {"resourceVersion": "7", "labels": {
+		"example.com/ens2f0.driver": null,
+		"example.com/ens1f0.driver": "i40e",
+		"example.com/ens1f0.vendor": "8086",
+		"example.com/ens1f0.device": "1572",
+		"example.com/pci-8086-1572": "true"}}}`, string((*patches)[0].Patch))
+	require.Equal(t, map[string]string{
+		"zone":                      "a",
+		"other.com/ens2f0.driver":   "mlx5_core",
+		"example.com/ens1f0.driver": "i40e",
+		"example.com/ens1f0.vendor": "8086",
+		"example.com/ens1f0.device": "1572",
+		"example.com/pci-8086-1572": "true",
+	}, node.Labels)
+
+	// labels are up to date
+	require.NoError(t, labelNode("node-1", "example.com", pfs, client))
+	require.Len(t, *patches, 1)
+}
+
+func TestLabelNodeRetriesConflicts(t *testing.T) {
+	defer func(interval time.Duration) {
+		nodePatchRetryInterval = interval
+	}(nodePatchRetryInterval)
+	nodePatchRetryInterval = 0
+	node := makeTestNode()
+	conflict := errors.NewConflict(schema.GroupResource{Resource: "nodes"}, "node-1", fmt.Errorf("changed"))
+	client, patches := fakeNodeClient(t, node, conflict)
+	require.NoError(t, labelNode("node-1", "example.com", []pf{{name: "ens1f0", driver: "i40e"}}, client))
+	require.Len(t, *patches, 2)
+	require.Equal(t, "i40e", node.Labels["example.com/ens1f0.driver"])
+
+	client, _ = fakeNodeClient(t, makeTestNode(), errors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "node-1", fmt.Errorf("denied")))
+	require.Error(t, labelNode("node-1", "example.com", []pf{{name: "ens1f0", driver: "i40e"}}, client))
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+const (
+	operstateMask = "sys/class/net/%s/operstate"
+	carrierMask   = "sys/class/net/%s/carrier"
+)
+
+// downOperstates are operational states of a link which can't carry traffic, see RFC 2863.
+// Some drivers don't report the state at all, unknown state is treated as up.
+var downOperstates = map[string]bool{
+	"down":           true,
+	"lowerlayerdown": true,
+	"notpresent":     true,
+}
+
+// readLinkDown returns true if interface reports down operational state or no carrier.
+// Carrier can't be read while interface is administratively down, operstate is down then.
+func readLinkDown(directory, name string) bool {
+	if data, err := ioutil.ReadFile(fmt.Sprintf(filepath.Join(directory, operstateMask), name)); err == nil &&
+		downOperstates[strings.TrimSpace(string(data))] {
+		return true
+	}
+	carrier, err := readInt(fmt.Sprintf(filepath.Join(directory, carrierMask), name))
+	return err == nil && carrier == 0
+}
+
+// linkCondition returns PFLinkDownCondition of a node with PFs, times are not set.
+func linkCondition(pfs []pf) v1.NodeCondition {
+	var down []string
+	for _, p := range pfs {
+		if p.linkDown {
+			down = append(down, p.name)
+		}
+	}
+	condition := v1.NodeCondition{Type: nodestate.PFLinkDownCondition}
+	switch {
+	case len(down) == 0:
+		condition.Status, condition.Reason = v1.ConditionFalse, nodestate.PFLinksUpReason
+		condition.Message = "Links of all PFs are up"
+	case len(down) == len(pfs):
+		condition.Status, condition.Reason = v1.ConditionTrue, nodestate.AllPFLinksDownReason
+		condition.Message = "Links of all PFs are down: " + strings.Join(down, ", ")
+	default:
+		condition.Status, condition.Reason = v1.ConditionTrue, nodestate.PFLinkDownReason
+		condition.Message = "Links of PFs are down: " + strings.Join(down, ", ")
+	}
+	return condition
+}
+
+// setLinkCondition patches PFLinkDownCondition in node status once it changed. Transition
+// time is kept while status is the same. Patch is rejected with a conflict if node was updated
+// since it was read, so a condition changed concurrently isn't overwritten by a stale one.
+func setLinkCondition(hostname string, pfs []pf, client kubernetes.Interface) error {
+	err := retryOnConflict(func() error {
+		node, err := client.Core().Nodes().Get(hostname, meta_v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		condition := linkCondition(pfs)
+		now := meta_v1.NewTime(time.Now())
+		condition.LastHeartbeatTime, condition.LastTransitionTime = now, now
+		for _, current := range node.Status.Conditions {
+			if current.Type != condition.Type {
+				continue
+			}
+			if current.Status == condition.Status && current.Reason == condition.Reason &&
+				current.Message == condition.Message {
+				return nil
+			}
+			if current.Status == condition.Status {
+				condition.LastTransitionTime = current.LastTransitionTime
+			}
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"resourceVersion": node.ResourceVersion},
+			"status":   map[string]interface{}{"conditions": []v1.NodeCondition{condition}},
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Patching condition of a node %s with %s\n", hostname, patch)
+		_, err = client.Core().Nodes().Patch(hostname, types.StrategicMergePatchType, patch, "status")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error patching condition of a node %s: %v", hostname, err)
+	}
+	return nil
+}
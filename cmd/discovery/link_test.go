@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+func TestReadLinkDown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sriov-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for name, files := range map[string]map[string]string{
+		"up":         {"operstate": "up\n", "carrier": "1\n"},
+		"unknown":    {"operstate": "unknown\n", "carrier": "1\n"},
+		"down":       {"operstate": "down\n"},
+		"lowerlayer": {"operstate": "lowerlayerdown\n", "carrier": "1\n"},
+		"nocarrier":  {"operstate": "unknown\n", "carrier": "0\n"},
+		"missing":    {},
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "sys/class/net", name), 0755))
+		for file, value := range files {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sys/class/net", name, file), []byte(value), 0644))
+		}
+	}
+	for name, down := range map[string]bool{
+		"up": false, "unknown": false, "down": true, "lowerlayer": true, "nocarrier": true, "missing": false,
+	} {
+		require.Equal(t, down, readLinkDown(dir, name), name)
+	}
+}
+
+func TestLinkDownVFsAreNotAllocatable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sriov-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer fakeFirmware(nil)()
+	defer fakeVFConfig(nil)()
+	writeFakePF(t, dir, pf{name: "ens1f0", numvfs: 2}, vf{driver: "iavf"}, vf{driver: "vfio-pci", driverOverride: "vfio-pci"})
+	writeFakePF(t, dir, pf{name: "ens1f1", numvfs: 1, linkDown: true}, vf{driver: "iavf"})
+
+	inv, err := discoverAll(dir, pfFilter{})
+	require.NoError(t, err)
+	capacity, allocatable := inv.resources()
+	require.Equal(t, map[v1.ResourceName]int64{
+		TotalVFsResource:  3,
+		NetdevVFsResource: 2,
+		DPDKVFsResource:   1,
+		"totalvfs-ens1f0": 2,
+		"totalvfs-ens1f1": 1,
+	}, resourceCounts(capacity))
+	require.Equal(t, map[v1.ResourceName]int64{
+		TotalVFsResource:  2,
+		NetdevVFsResource: 1,
+		DPDKVFsResource:   1,
+		"totalvfs-ens1f0": 2,
+		"totalvfs-ens1f1": 0,
+	}, resourceCounts(allocatable))
+	require.True(t, nodeState("node-1", inv).Status.PFs[1].LinkDown)
+}
+
+func TestLinkCondition(t *testing.T) {
+	up, down := pf{name: "ens1f0"}, pf{name: "ens1f1", linkDown: true}
+	require.Equal(t, v1.NodeCondition{Type: nodestate.PFLinkDownCondition, Status: v1.ConditionFalse,
+		Reason: nodestate.PFLinksUpReason, Message: "Links of all PFs are up"}, linkCondition([]pf{up}))
+	require.Equal(t, v1.NodeCondition{Type: nodestate.PFLinkDownCondition, Status: v1.ConditionTrue,
+		Reason: nodestate.PFLinkDownReason, Message: "Links of PFs are down: ens1f1"}, linkCondition([]pf{up, down}))
+	require.Equal(t, v1.NodeCondition{Type: nodestate.PFLinkDownCondition, Status: v1.ConditionTrue,
+		Reason: nodestate.AllPFLinksDownReason, Message: "Links of all PFs are down: ens1f1"}, linkCondition([]pf{down}))
+}
+
+func nodeCondition(node *v1.Node, conditionType v1.NodeConditionType) *v1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == conditionType {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestSetLinkCondition(t *testing.T) {
+	node := makeTestNode()
+	client, patches := fakeNodeClient(t, node)
+	up, down := pf{name: "ens1f0"}, pf{name: "ens1f1", linkDown: true}
+	require.NoError(t, setLinkCondition("node-1", []pf{up, down}, client))
+	require.Len(t, *patches, 1)
+	require.Equal(t, "status", (*patches)[0].GetSubresource())
+	condition := nodeCondition(node, nodestate.PFLinkDownCondition)
+	require.NotNil(t, condition)
+	require.Equal(t, v1.ConditionTrue, condition.Status)
+	require.Equal(t, nodestate.PFLinkDownReason, condition.Reason)
+	// other conditions are kept
+	require.NotNil(t, nodeCondition(node, v1.NodeReady))
+
+	// unchanged condition isn't patched
+	require.NoError(t, setLinkCondition("node-1", []pf{up, down}, client))
+	require.Len(t, *patches, 1)
+
+	// transition time is kept while status is the same
+	transition := meta_v1.NewTime(time.Now().Add(-time.Hour))
+	condition.LastTransitionTime = transition
+	down2 := pf{name: "ens1f0", linkDown: true}
+	require.NoError(t, setLinkCondition("node-1", []pf{down2, down}, client))
+	require.Len(t, *patches, 2)
+	condition = nodeCondition(node, nodestate.PFLinkDownCondition)
+	require.Equal(t, nodestate.AllPFLinksDownReason, condition.Reason)
+	require.Equal(t, transition.Unix(), condition.LastTransitionTime.Unix())
+
+	require.NoError(t, setLinkCondition("node-1", []pf{up}, client))
+	condition = nodeCondition(node, nodestate.PFLinkDownCondition)
+	require.Equal(t, v1.ConditionFalse, condition.Status)
+	require.True(t, condition.LastTransitionTime.After(transition.Time))
+}
+
+func TestSetLinkConditionRetriesConflicts(t *testing.T) {
+	defer func(interval time.Duration) {
+		nodePatchRetryInterval = interval
+	}(nodePatchRetryInterval)
+	nodePatchRetryInterval = 0
+	node := makeTestNode()
+	conflict := errors.NewConflict(schema.GroupResource{Resource: "nodes"}, "node-1", fmt.Errorf("changed"))
+	client, patches := fakeNodeClient(t, node, conflict)
+	require.NoError(t, setLinkCondition("node-1", []pf{{name: "ens1f0", linkDown: true}}, client))
+	require.Len(t, *patches, 2)
+	var patch struct {
+		Metadata meta_v1.ObjectMeta `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal((*patches)[1].Patch, &patch))
+	require.Equal(t, "7", patch.Metadata.ResourceVersion)
+	require.Equal(t, nodestate.AllPFLinksDownReason, nodeCondition(node, nodestate.PFLinkDownCondition).Reason)
+}
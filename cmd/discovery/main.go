@@ -1,37 +1,92 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/pkg/api/v1"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"time"
 
-	"strings"
-
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
 	"github.com/spf13/pflag"
 )
 
 type options struct {
 	device     string
+	auto       bool
+	filter     pfFilter
 	kubeconfig string
 	interval   time.Duration
 	nodename   string
 	directory  string
+	attempts   int
+	backoff    backoff
+	// healthListen is an address of the health endpoint, disabled if empty
+	healthListen   string
+	unhealthyAfter int
+	watch          bool
+	pollInterval   time.Duration
+	debounce       time.Duration
+	// labelPrefix of labels with PF details, labels are not published if empty
+	labelPrefix string
+	nodeState   bool
+	// policy is namespace/name of a ConfigMap with configuration of PFs, PFs aren't configured if empty
+	policy string
+	// cleanup removes everything discovery published about the node instead of discovery
+	cleanup bool
 }
 
 func (o *options) register() {
 	pflag.StringVar(&o.device, "device", "eth0", "Device to use for VFs.")
+	pflag.BoolVar(&o.auto, "auto", false, "Discover every SR-IOV capable device instead of a single --device.")
+	pflag.StringSliceVar(&o.filter.includeNames, "include-devices", nil,
+		"Patterns of device names to discover in auto mode, e.g. ens*.")
+	pflag.StringSliceVar(&o.filter.excludeNames, "exclude-devices", nil,
+		"Patterns of device names to skip in auto mode.")
+	pflag.StringSliceVar(&o.filter.includeIDs, "include-ids", nil,
+		"Patterns of PCI vendor:device IDs to discover in auto mode, e.g. 8086:*.")
+	pflag.StringSliceVar(&o.filter.excludeIDs, "exclude-ids", nil,
+		"Patterns of PCI vendor:device IDs to skip in auto mode.")
+	pflag.StringSliceVar(&o.filter.includeDrivers, "include-drivers", nil,
+		"Patterns of PF drivers to discover in auto mode, e.g. i40e.")
+	pflag.StringSliceVar(&o.filter.excludeDrivers, "exclude-drivers", nil,
+		"Patterns of PF drivers to skip in auto mode.")
 	pflag.StringVar(&o.kubeconfig, "kubeconfig", "", "Kubernetes config file.")
 	pflag.DurationVarP(&o.interval, "interval", "i", 0, "If set discovery will run every specified interval.")
+	pflag.BoolVar(&o.watch, "watch", false,
+		"Run as a daemon publishing VFs once link events or polling detect a change.")
+	pflag.DurationVar(&o.pollInterval, "poll-interval", 30*time.Second,
+		"How often VFs are rediscovered in watch mode in addition to link events.")
+	pflag.DurationVar(&o.debounce, "debounce", 2*time.Second,
+		"Pause after a link event before VFs are rediscovered in watch mode.")
+	pflag.IntVar(&o.attempts, "attempts", 5,
+		"Number of attempts before discovery gives up, periodic discovery retries forever.")
+	pflag.DurationVar(&o.backoff.initial, "backoff", time.Second,
+		"Pause after the first failed attempt, it doubles after every consecutive failure.")
+	pflag.DurationVar(&o.backoff.max, "max-backoff", 5*time.Minute, "The longest pause between failed attempts.")
+	pflag.StringVar(&o.labelPrefix, "label-prefix", DefaultLabelPrefix,
+		"Prefix of node labels with PF details, labels are not published if empty.")
+	pflag.BoolVar(&o.nodeState, "node-state", false,
+		"Write SriovNodeState custom resource of the node, its definition has to be created beforehand.")
+	pflag.StringVar(&o.policy, "policy", "",
+		"Namespace/name of a ConfigMap with numbers of VFs and VF drivers of PFs to apply before discovery.")
+	pflag.BoolVar(&o.cleanup, "cleanup", false,
+		"Remove VF resources, labels, link condition and SriovNodeState of the node and exit.")
+	pflag.StringVar(&o.healthListen, "health-listen", "",
+		"Socket to serve status of the last attempts on /healthz, disabled if empty.")
+	pflag.IntVar(&o.unhealthyAfter, "unhealthy-after", 3,
+		"Number of consecutive failed attempts after which /healthz reports an error.")
 	pflag.StringVarP(&o.directory, "directory", "d", "/",
 		"Base directory for sriov total vfs location, mainly used in tests")
 	hostname, err := os.Hostname()
@@ -50,6 +105,11 @@ func (o *options) registerAndParse() {
 	o.parse()
 }
 
+const nodePatchRetries = 5
+
+// nodePatchRetryInterval is a pause before a failed patch is retried.
+var nodePatchRetryInterval = time.Second
+
 const (
 	sriovTotalvfsMask                 = "sys/class/net/%s/device/sriov_totalvfs"
 	TotalVFsResource  v1.ResourceName = "totalvfs"
@@ -60,65 +120,264 @@ func main() {
 
 	opts := new(options)
 	opts.registerAndParse()
-	err := periodically(opts.interval, func() error {
-		deviceFile := fmt.Sprintf(filepath.Join(opts.directory, sriovTotalvfsMask), opts.device)
-		log.Printf("Total VFs number will be discovered from %s\n", deviceFile)
-		totalVfsBytes, err := ioutil.ReadFile(deviceFile)
-		if err != nil {
-			log.Fatalf("Error discovering totalvfs from file %s; %v", deviceFile, err)
+	if err := opts.filter.validate(); err != nil {
+		log.Fatal(err)
+	}
+	if err := validateLabelPrefix(opts.labelPrefix); err != nil {
+		log.Fatal(err)
+	}
+	var policyNamespace, policyName string
+	if opts.policy != "" {
+		var err error
+		if policyNamespace, policyName, err = splitPolicyName(opts.policy); err != nil {
+			log.Fatal(err)
 		}
-		totalVfs := resource.MustParse(strings.TrimSpace(string(totalVfsBytes)))
-		log.Printf("Using kubernetes config %s\n", opts.kubeconfig)
-		config, err := clientcmd.BuildConfigFromFlags("", opts.kubeconfig)
-		if err != nil {
+	}
+	log.Printf("Using kubernetes config %s\n", opts.kubeconfig)
+	config, err := clientcmd.BuildConfigFromFlags("", opts.kubeconfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var states nodestate.Interface
+	// cleanup deletes SriovNodeState even if it isn't written anymore
+	if opts.nodeState || opts.cleanup {
+		if states, err = nodestate.NewForConfig(config); err != nil {
 			log.Fatal(err)
 		}
-		client, err := kubernetes.NewForConfig(config)
+	}
+	st := &status{unhealthyAfter: opts.unhealthyAfter}
+	if opts.healthListen != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/healthz", st)
+		go func() {
+			log.Fatal(http.ListenAndServe(opts.healthListen, mux))
+		}()
+	}
+	if opts.cleanup {
+		r := &runner{
+			attempts: opts.attempts,
+			backoff:  &opts.backoff,
+			status:   st,
+			after:    time.After,
+		}
+		if err := r.run(nil, func() error {
+			return cleanup(opts.nodename, opts.labelPrefix, client, states)
+		}); err != nil {
+			log.Fatalf("Error cleaning up a node %s: %v\n", opts.nodename, err)
+		}
+		os.Exit(0)
+	}
+	discover := func() (inventory, error) {
+		if opts.policy != "" {
+			// VFs are discovered as they are if policy can't be applied
+			if err := configure(policyNamespace, policyName, opts.nodename, opts.directory, client, states); err != nil {
+				log.Println(err)
+			}
+		}
+		var inv inventory
+		var err error
+		if opts.auto {
+			inv, err = discoverAll(opts.directory, opts.filter)
+		} else {
+			inv, err = discoverDevice(opts.directory, opts.device)
+		}
 		if err != nil {
-			log.Fatal(err)
+			return inv, fmt.Errorf("error discovering VFs: %v", err)
+		}
+		return inv, nil
+	}
+	publish := func(inv inventory) error {
+		capacity, allocatable := inv.resources()
+		if err := doDiscovery(opts.nodename, capacity, allocatable, client); err != nil {
+			return err
+		}
+		if err := setLinkCondition(opts.nodename, inv.pfs, client); err != nil {
+			return err
 		}
-		if err := doDiscovery(opts.nodename, totalVfs, client); err != nil {
-			log.Fatalf("Error updating totalvfs for a node %s: %v\n", opts.nodename, err)
+		if opts.labelPrefix != "" {
+			if err := labelNode(opts.nodename, opts.labelPrefix, inv.pfs, client); err != nil {
+				return err
+			}
 		}
-		return nil
+		if states == nil {
+			return nil
+		}
+		return writeNodeState(nodeState(opts.nodename, inv), states)
+	}
+	if opts.watch {
+		stopCh := make(chan struct{})
+		events := make(chan struct{}, 1)
+		if err := subscribeLinkEvents(events, stopCh); err != nil {
+			log.Printf("Error subscribing to link events, VFs will be polled every %v: %v\n", opts.pollInterval, err)
+		}
+		w := &watcher{
+			discover:     discover,
+			publish:      publish,
+			pollInterval: opts.pollInterval,
+			debounce:     opts.debounce,
+			backoff:      &opts.backoff,
+			status:       st,
+			after:        time.After,
+		}
+		w.run(events, stopCh)
+		return
+	}
+	r := &runner{
+		interval: opts.interval,
+		attempts: opts.attempts,
+		backoff:  &opts.backoff,
+		status:   st,
+		after:    time.After,
+	}
+	err = r.run(nil, func() error {
+		inv, err := discover()
+		if err != nil {
+			return err
+		}
+		return publish(inv)
 	})
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("Error updating vfs for a node %s: %v\n", opts.nodename, err)
 	}
 	os.Exit(0)
 }
 
-func doDiscovery(hostname string, totalVfs resource.Quantity, client *kubernetes.Clientset) error {
-	for i := 3; i > 0; i-- {
-		log.Printf("Fetching a node %s from kubernetes API. Retries left %d\n", hostname, i-1)
-		node, err := client.Nodes().Get(hostname, meta_v1.GetOptions{})
-		if err != nil {
-			log.Printf("Getting a node %s failed.\n", hostname)
+// configure applies policy of a node, if there is one, to PFs without allocated VFs.
+func configure(namespace, name, nodename, directory string, client kubernetes.Interface, states nodestate.Interface) error {
+	pol, err := readPolicy(namespace, name, nodename, client)
+	if err != nil || pol == nil {
+		return err
+	}
+	allocated, err := allocatedPFs(nodename, client, states)
+	if err != nil {
+		return err
+	}
+	return applyPolicy(directory, pol, allocated)
+}
+
+// discoverDevice counts VFs of a single device.
+func discoverDevice(directory, device string) (inventory, error) {
+	log.Printf("VFs will be discovered from device %s\n", device)
+	p, err := readPF(directory, device)
+	if err != nil {
+		return inventory{}, fmt.Errorf("error discovering device %s: %v", device, err)
+	}
+	return discoverInventory(directory, []pf{p})
+}
+
+// discoverAll counts VFs of every PF matching a filter.
+func discoverAll(directory string, filter pfFilter) (inventory, error) {
+	pfs, err := discoverPFs(directory)
+	if err != nil {
+		return inventory{}, err
+	}
+	matched := make([]pf, 0, len(pfs))
+	for _, p := range pfs {
+		if !filter.match(p) {
+			log.Printf("Skipping device %s %s driver %q\n", p.name, p.id(), p.driver)
 			continue
 		}
-		log.Printf("Updating a node %s with totalvfs %s\n", hostname, totalVfs)
-		// TODO a patch request
-		node.Status.Capacity[TotalVFsResource] = totalVfs
-		node.Status.Allocatable[TotalVFsResource] = totalVfs
-		_, err = client.Nodes().UpdateStatus(node)
+		matched = append(matched, p)
+	}
+	return discoverInventory(directory, matched)
+}
+
+// discoverInventory counts VFs of PFs, both combined and of every PF.
+func discoverInventory(directory string, pfs []pf) (inventory, error) {
+	inv := newInventory()
+	for _, p := range pfs {
+		virtfnGlob := fmt.Sprintf(filepath.Join(directory, sriovVirtfnMask), p.name)
+		vfs, err := discoverVFs(virtfnGlob)
 		if err != nil {
-			log.Printf("Updating a node %s failed.\n", hostname)
-			continue
+			return inventory{}, fmt.Errorf("error discovering VFs from %s: %v", virtfnGlob, err)
 		}
-		return nil
+		if configs, err := readVFConfig(p.name); err != nil {
+			log.Printf("Error reading MAC and VLAN of VFs of device %s: %v\n", p.name, err)
+		} else {
+			applyVFConfig(vfs, configs)
+		}
+		log.Printf("Discovered device %s %s driver %q with %d of %d VFs configured, link down %v\n",
+			p.name, p.id(), p.driver, p.numvfs, p.totalvfs, p.linkDown)
+		inv.addPF(p, vfs)
 	}
-	return nil
+	return inv, nil
 }
 
-func periodically(interval time.Duration, f func() error) error {
-	for {
-		if err := f(); err != nil {
+// doDiscovery patches VF resources in node status, other resources and fields are left intact.
+// Resources published before but not discovered anymore, e.g. of a PF that disappeared, are
+// removed. Node is read again and patch is retried if it conflicts with a concurrent update.
+func doDiscovery(hostname string, capacity, allocatable v1.ResourceList, client kubernetes.Interface) error {
+	err := retryOnConflict(func() error {
+		node, err := client.Core().Nodes().Get(hostname, meta_v1.GetOptions{})
+		if err != nil {
 			return err
 		}
-		if interval > 0 {
-			time.Sleep(interval)
-			continue
+		patch, err := nodeStatusPatch(node.ResourceVersion, node.Status, capacity, allocatable)
+		if err != nil {
+			return err
+		}
+		log.Printf("Patching a node %s with vfs capacity %v allocatable %v\n", hostname, capacity, allocatable)
+		_, err = client.Core().Nodes().Patch(hostname, types.StrategicMergePatchType, patch, "status")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error patching a node %s: %v", hostname, err)
+	}
+	return nil
+}
+
+// nodeStatusPatch returns strategic merge patch of node status with VF resources only.
+// VF resources of current status missing from the new ones are set to null, which deletes them.
+// Patch is rejected with a conflict if node was updated since resourceVersion was read,
+// otherwise a resource published concurrently could be removed by a stale null.
+func nodeStatusPatch(resourceVersion string, current v1.NodeStatus, capacity, allocatable v1.ResourceList) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"resourceVersion": resourceVersion},
+		"status": map[string]interface{}{
+			"capacity":    resourcesPatch(current.Capacity, capacity),
+			"allocatable": resourcesPatch(current.Allocatable, allocatable),
+		},
+	})
+}
+
+func resourcesPatch(current, desired v1.ResourceList) map[v1.ResourceName]interface{} {
+	patch := map[v1.ResourceName]interface{}{}
+	for res := range current {
+		if _, exists := desired[res]; !exists && ownedResource(res) {
+			patch[res] = nil
+		}
+	}
+	for res, quantity := range desired {
+		patch[res] = quantity
+	}
+	return patch
+}
+
+// ownedResource returns true for resources published by discovery.
+func ownedResource(res v1.ResourceName) bool {
+	switch res {
+	case TotalVFsResource, NetdevVFsResource, DPDKVFsResource:
+		return true
+	}
+	return strings.HasPrefix(string(res), fmt.Sprintf(pfResourceMask, ""))
+}
+
+// retryOnConflict calls update until it succeeds, fails with other error than a conflict
+// or retries run out. update has to read the object again, so a retry doesn't repeat the
+// conflict, and return errors of the client as they are. Other errors, e.g. timeouts, are
+// retried by the runner with a backoff, so retries are not multiplied.
+func retryOnConflict(update func() error) error {
+	var err error
+	for i := nodePatchRetries; i > 0; i-- {
+		if err = update(); err == nil || !errors.IsConflict(err) {
+			return err
 		}
-		return nil
+		log.Printf("Update failed, retries left %d: %v\n", i-1, err)
+		time.Sleep(nodePatchRetryInterval)
 	}
+	return err
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+	core "k8s.io/client-go/testing"
+)
+
+// fakeNodeClient serves a single node and applies strategic merge patches to it,
+// fake clientset doesn't implement patches. Errors are returned by the first patches.
+func fakeNodeClient(t *testing.T, node *v1.Node, patchErrs ...error) (*fake.Clientset, *[]core.PatchActionImpl) {
+	var patches []core.PatchActionImpl
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("get", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		copied := *node
+		return true, &copied, nil
+	})
+	client.PrependReactor("patch", "nodes", func(action core.Action) (bool, runtime.Object, error) {
+		patch := action.(core.PatchActionImpl)
+		patches = append(patches, patch)
+		if len(patchErrs) != 0 {
+			err := patchErrs[0]
+			patchErrs = patchErrs[1:]
+			return true, nil, err
+		}
+		original, err := json.Marshal(node)
+		require.NoError(t, err)
+		patched, err := strategicpatch.StrategicMergePatch(original, patch.Patch, v1.Node{})
+		require.NoError(t, err)
+		*node = v1.Node{}
+		require.NoError(t, json.Unmarshal(patched, node))
+		return true, node, nil
+	})
+	return client, &patches
+}
+
+func makeTestNode() *v1.Node {
+	return &v1.Node{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "node-1", Labels: map[string]string{"zone": "a"}, ResourceVersion: "7"},
+		Status: v1.NodeStatus{
+			Capacity: v1.ResourceList{
+				v1.ResourceCPU:   resource.MustParse("8"),
+				TotalVFsResource: resource.MustParse("4"),
+			},
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:   resource.MustParse("7"),
+				TotalVFsResource: resource.MustParse("4"),
+			},
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			Addresses:  []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+		},
+	}
+}
+
+func TestDoDiscoveryPatchesOnlyVFs(t *testing.T) {
+	node := makeTestNode()
+	client, patches := fakeNodeClient(t, node)
+	capacity := v1.ResourceList{TotalVFsResource: resource.MustParse("6"), DPDKVFsResource: resource.MustParse("2")}
+	allocatable := v1.ResourceList{TotalVFsResource: resource.MustParse("5"), DPDKVFsResource: resource.MustParse("1")}
+	require.NoError(t, doDiscovery("node-1", capacity, allocatable, client))
+
+	require.Len(t, *patches, 1)
+	require.Equal(t, "status", (*patches)[0].GetSubresource())
+	require.JSONEq(t, `{"metadata": {"resourceVersion": "7"}, "status": {
+		"capacity": {"totalvfs": "6", "dpdkvfs": "2"},
+		"allocatable": {"totalvfs": "5", "dpdkvfs": "1"}}}`, string((*patches)[0].Patch))
+
+	expected := makeTestNode()
+	expected.Status.Capacity[TotalVFsResource] = resource.MustParse("6")
+	expected.Status.Capacity[DPDKVFsResource] = resource.MustParse("2")
+	expected.Status.Allocatable[TotalVFsResource] = resource.MustParse("5")
+	expected.Status.Allocatable[DPDKVFsResource] = resource.MustParse("1")
+	expectedData, err := json.Marshal(expected)
+	require.NoError(t, err)
+	nodeData, err := json.Marshal(node)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expectedData), string(nodeData))
+}
+
+func TestDoDiscoveryRetriesConflicts(t *testing.T) {
+	defer func(interval time.Duration) {
+		nodePatchRetryInterval = interval
+	}(nodePatchRetryInterval)
+	nodePatchRetryInterval = 0
+	nodeResource := schema.GroupResource{Resource: "nodes"}
+	conflict := errors.NewConflict(nodeResource, "node-1", fmt.Errorf("object was modified"))
+	resources := v1.ResourceList{TotalVFsResource: resource.MustParse("2")}
+
+	node := makeTestNode()
+	client, patches := fakeNodeClient(t, node, conflict, conflict)
+	require.NoError(t, doDiscovery("node-1", resources, resources, client))
+	require.Len(t, *patches, 3)
+	total := node.Status.Allocatable[TotalVFsResource]
+	require.Equal(t, int64(2), total.Value())
+
+	client, patches = fakeNodeClient(t, makeTestNode(), errors.NewNotFound(nodeResource, "node-1"))
+	require.Error(t, doDiscovery("node-1", resources, resources, client))
+	require.Len(t, *patches, 1, "only conflicts should be retried")
+
+	client, patches = fakeNodeClient(t, makeTestNode(), errors.NewServerTimeout(nodeResource, "patch", 1))
+	require.Error(t, doDiscovery("node-1", resources, resources, client))
+	require.Len(t, *patches, 1, "timeouts should be retried by the runner")
+
+	conflicts := make([]error, nodePatchRetries)
+	for i := range conflicts {
+		conflicts[i] = conflict
+	}
+	client, patches = fakeNodeClient(t, makeTestNode(), conflicts...)
+	require.Error(t, doDiscovery("node-1", resources, resources, client))
+	require.Len(t, *patches, nodePatchRetries)
+}
+
+func TestDoDiscoveryRemovesDisappearedPFs(t *testing.T) {
+	node := makeTestNode()
+	for _, resources := range []v1.ResourceList{node.Status.Capacity, node.Status.Allocatable} {
+		resources[pfResource("ens2f0")] = resource.MustParse("2")
+		resources[pfResource("ens2f1")] = resource.MustParse("2")
+	}
+	client, patches := fakeNodeClient(t, node)
+	resources := v1.ResourceList{TotalVFsResource: resource.MustParse("2"), pfResource("ens2f1"): resource.MustParse("2")}
+	require.NoError(t, doDiscovery("node-1", resources, resources, client))
+
+	require.Len(t, *patches, 1)
+	require.JSONEq(t, `{"metadata": {"resourceVersion": "7"}, "status": {
+		"capacity": {"totalvfs": "2", "totalvfs-ens2f0": null, "totalvfs-ens2f1": "2"},
+		"allocatable": {"totalvfs": "2", "totalvfs-ens2f0": null, "totalvfs-ens2f1": "2"}}}`,
+		string((*patches)[0].Patch))
+	for _, resources := range []v1.ResourceList{node.Status.Capacity, node.Status.Allocatable} {
+		require.NotContains(t, resources, pfResource("ens2f0"))
+		require.Contains(t, resources, pfResource("ens2f1"))
+		require.Contains(t, resources, v1.ResourceCPU)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log"
+	"os"
+	"syscall"
+)
+
+// rtmgrpLink is a multicast group of link notifications, RTMGRP_LINK in linux/rtnetlink.h.
+const rtmgrpLink = 0x1
+
+// subscribeLinkEvents notifies events about every link added, changed or removed.
+// Events are dropped while the previous one wasn't consumed, a single one triggers discovery.
+func subscribeLinkEvents(events chan<- struct{}, stopCh <-chan struct{}) error {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return os.NewSyscallError("socket", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: rtmgrpLink}); err != nil {
+		syscall.Close(fd)
+		return os.NewSyscallError("bind", err)
+	}
+	go func() {
+		<-stopCh
+		syscall.Close(fd)
+	}()
+	go func() {
+		buf := make([]byte, os.Getpagesize())
+		for {
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+			if err != nil {
+				log.Printf("Stopped receiving link events: %v\n", err)
+				return
+			}
+			msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+			if err != nil {
+				log.Printf("Error parsing link event: %v\n", err)
+				continue
+			}
+			for _, msg := range msgs {
+				if msg.Header.Type != syscall.RTM_NEWLINK && msg.Header.Type != syscall.RTM_DELLINK {
+					continue
+				}
+				select {
+				case events <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// subscribeLinkEvents isn't supported outside of linux, discovery relies on polling.
+func subscribeLinkEvents(events chan<- struct{}, stopCh <-chan struct{}) error {
+	return fmt.Errorf("link events are not supported on %s", runtime.GOOS)
+}
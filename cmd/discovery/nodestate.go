@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+// vfConfig is MAC and VLAN configured for a VF by the PF driver.
+type vfConfig struct {
+	mac  string
+	vlan int
+}
+
+// applyVFConfig sets MAC and VLAN of VFs configured by the PF driver, VFs keep MAC of their
+// interfaces if PF doesn't report it.
+func applyVFConfig(vfs []vf, configs map[int]vfConfig) {
+	for i := range vfs {
+		config, exists := configs[vfs[i].index]
+		if !exists {
+			continue
+		}
+		if config.mac != "" {
+			vfs[i].mac = config.mac
+		}
+		vfs[i].vlan = config.vlan
+	}
+}
+
+// nodeState returns SriovNodeState of a node with every PF of an inventory.
+func nodeState(hostname string, inv inventory) *nodestate.SriovNodeState {
+	state := &nodestate.SriovNodeState{Status: nodestate.SriovNodeStateStatus{PFs: []nodestate.PF{}}}
+	state.Name = hostname
+	for _, p := range inv.pfs {
+		statePF := nodestate.PF{
+			Name:       p.name,
+			PCIAddress: p.pciAddress,
+			Vendor:     p.vendor,
+			Device:     p.device,
+			Driver:     p.driver,
+			NUMANode:   p.numaNode,
+			LinkDown:   p.linkDown,
+			TotalVFs:   p.totalvfs,
+			NumVFs:     p.numvfs,
+			VFs:        []nodestate.VF{},
+		}
+		for _, v := range inv.vfs[p.name] {
+			stateVF := nodestate.VF{
+				Index:      v.index,
+				PCIAddress: v.pciAddress,
+				Driver:     v.driver,
+				Netdev:     v.netdev,
+				MAC:        v.mac,
+				VLAN:       v.vlan,
+				InUse:      v.inUse,
+			}
+			if v.driver != "" {
+				stateVF.Mode = driverMode(v.driver)
+			}
+			statePF.VFs = append(statePF.VFs, stateVF)
+		}
+		state.Status.PFs = append(state.Status.PFs, statePF)
+	}
+	return state
+}
+
+// writeNodeState creates SriovNodeState of a node or updates its status if it changed.
+// Update is retried with the state read again if it conflicts with a concurrent update.
+func writeNodeState(state *nodestate.SriovNodeState, client nodestate.Interface) error {
+	err := retryOnConflict(func() error {
+		current, err := client.Get(state.Name)
+		if errors.IsNotFound(err) {
+			log.Printf("Creating SriovNodeState of a node %s\n", state.Name)
+			if _, err = client.Create(state); !errors.IsAlreadyExists(err) {
+				return err
+			}
+			// state was created concurrently, so it's updated instead
+			current, err = client.Get(state.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(current.Status, state.Status) {
+			return nil
+		}
+		log.Printf("Updating SriovNodeState of a node %s\n", state.Name)
+		current.Status = state.Status
+		_, err = client.Update(current)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error writing SriovNodeState of a node %s: %v", state.Name, err)
+	}
+	return nil
+}
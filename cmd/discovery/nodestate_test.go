@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+func TestNodeState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sriov-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer fakeFirmware(nil)()
+	defer fakeVFConfig(map[string]map[int]vfConfig{
+		"ens1f0": {0: {mac: "52:54:00:00:00:01", vlan: 100}, 1: {vlan: 200}},
+	})()
+	writeFakePF(t, dir,
+		pf{name: "ens1f0", pciAddress: "0000:03:00.0", numaNode: 1, vendor: "8086", device: "1572",
+			driver: "i40e", totalvfs: 8, numvfs: 3},
+		vf{pciAddress: "0000:03:02.0", driver: "iavf", netdev: "ens1f0v0", inUse: true},
+		vf{pciAddress: "0000:03:02.1", driver: "iavf", netdev: "ens1f0v1", mac: "52:54:00:00:00:02"},
+		vf{pciAddress: "0000:03:02.2", driver: "vfio-pci", driverOverride: "vfio-pci"},
+	)
+
+	inv, err := discoverAll(dir, pfFilter{})
+	require.NoError(t, err)
+	state := nodeState("node-1", inv)
+	require.Equal(t, "node-1", state.Name)
+	require.Equal(t, []nodestate.PF{{
+		Name: "ens1f0", PCIAddress: "0000:03:00.0", Vendor: "8086", Device: "1572", Driver: "i40e",
+		NUMANode: 1, TotalVFs: 8, NumVFs: 3,
+		VFs: []nodestate.VF{
+			{Index: 0, PCIAddress: "0000:03:02.0", Driver: "iavf", Mode: "netdev", Netdev: "ens1f0v0",
+				MAC: "52:54:00:00:00:01", VLAN: 100, InUse: true},
+			{Index: 1, PCIAddress: "0000:03:02.1", Driver: "iavf", Mode: "netdev", Netdev: "ens1f0v1",
+				MAC: "52:54:00:00:00:02", VLAN: 200},
+			{Index: 2, PCIAddress: "0000:03:02.2", Driver: "vfio-pci", Mode: "dpdk"},
+		},
+	}}, state.Status.PFs)
+}
+
+// conflictingStates fails the first updates with a conflict.
+type conflictingStates struct {
+	*nodestate.Fake
+	conflicts int
+	updates   int
+}
+
+func (c *conflictingStates) Update(state *nodestate.SriovNodeState) (*nodestate.SriovNodeState, error) {
+	c.updates++
+	if c.conflicts > 0 {
+		c.conflicts--
+		return nil, errors.NewConflict(
+			schema.GroupResource{Resource: nodestate.Resource}, state.Name, fmt.Errorf("changed"))
+	}
+	return c.Fake.Update(state)
+}
+
+func TestWriteNodeState(t *testing.T) {
+	defer func(interval time.Duration) {
+		nodePatchRetryInterval = interval
+	}(nodePatchRetryInterval)
+	nodePatchRetryInterval = 0
+	client := &conflictingStates{Fake: nodestate.NewFake(), conflicts: 1}
+	state := &nodestate.SriovNodeState{Status: nodestate.SriovNodeStateStatus{
+		PFs: []nodestate.PF{{Name: "ens1f0", NumVFs: 1, VFs: []nodestate.VF{{Index: 0, Driver: "iavf"}}}},
+	}}
+	state.Name = "node-1"
+	require.NoError(t, writeNodeState(state, client))
+	created, err := client.Get("node-1")
+	require.NoError(t, err)
+	require.Equal(t, state.Status, created.Status)
+
+	// unchanged state isn't updated
+	require.NoError(t, writeNodeState(state, client))
+	require.Equal(t, 0, client.updates)
+
+	state.Status.PFs[0].VFs[0].InUse = true
+	require.NoError(t, writeNodeState(state, client))
+	require.Equal(t, 2, client.updates)
+	updated, err := client.Get("node-1")
+	require.NoError(t, err)
+	require.True(t, updated.Status.PFs[0].VFs[0].InUse)
+	require.NotEqual(t, created.ResourceVersion, updated.ResourceVersion)
+}
+
+// racingStates creates a stale state right before the first create, as a concurrent writer would.
+type racingStates struct {
+	*nodestate.Fake
+}
+
+func (r racingStates) Create(state *nodestate.SriovNodeState) (*nodestate.SriovNodeState, error) {
+	stale := state.DeepCopy()
+	stale.Status.PFs = nil
+	if _, err := r.Fake.Create(stale); err != nil {
+		return nil, err
+	}
+	return r.Fake.Create(state)
+}
+
+func TestWriteNodeStateCreatedConcurrently(t *testing.T) {
+	client := racingStates{nodestate.NewFake()}
+	state := &nodestate.SriovNodeState{Status: nodestate.SriovNodeStateStatus{
+		PFs: []nodestate.PF{{Name: "ens1f0", NumVFs: 1}},
+	}}
+	state.Name = "node-1"
+	require.NoError(t, writeNodeState(state, client))
+	written, err := client.Get("node-1")
+	require.NoError(t, err)
+	require.Equal(t, state.Status, written.Status)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+const (
+	sriovTotalvfsGlob = "sys/class/net/*/device/sriov_totalvfs"
+	sriovNumvfsMask   = "sys/class/net/%s/device/sriov_numvfs"
+	pciVendorMask     = "sys/class/net/%s/device/vendor"
+	pciDeviceMask     = "sys/class/net/%s/device/device"
+	pciDriverMask     = "sys/class/net/%s/device/driver"
+	linkSpeedMask     = "sys/class/net/%s/speed"
+	pciDeviceLinkMask = "sys/class/net/%s/device"
+	numaNodeMask      = "sys/class/net/%s/device/numa_node"
+
+	// pfResourceMask is a resource with the number of VFs of a single PF.
+	pfResourceMask = "totalvfs-%s"
+)
+
+// pf is a physical function capable of SR-IOV.
+type pf struct {
+	name string
+	// vendor and device are PCI IDs without 0x prefix, e.g. 8086 and 1572
+	vendor   string
+	device   string
+	driver   string
+	totalvfs int64
+	// numvfs is a number of VFs configured on the PF
+	numvfs int64
+	// firmware is a version reported by the driver, empty if unknown
+	firmware string
+	// speed of the link in Mb/s, zero if link is down or unknown
+	speed int64
+	// pciAddress is empty if device isn't a link to a PCI device
+	pciAddress string
+	// numaNode is -1 if host doesn't report it
+	numaNode int
+	// linkDown is true if link can't carry traffic of VFs
+	linkDown bool
+}
+
+// id returns PCI vendor and device IDs as vendor:device.
+func (p pf) id() string {
+	return p.vendor + ":" + p.device
+}
+
+// pfFilter selects PFs by interface name, PCI vendor:device ID and driver. Every value is
+// a shell pattern, e.g. ens* or 8086:*. PF matches if it matches every non-empty include
+// list and none of the exclude lists.
+type pfFilter struct {
+	includeNames   []string
+	excludeNames   []string
+	includeIDs     []string
+	excludeIDs     []string
+	includeDrivers []string
+	excludeDrivers []string
+}
+
+func (f pfFilter) validate() error {
+	for _, patterns := range [][]string{
+		f.includeNames, f.excludeNames, f.includeIDs, f.excludeIDs, f.includeDrivers, f.excludeDrivers,
+	} {
+		for _, pattern := range patterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (f pfFilter) match(p pf) bool {
+	for _, rule := range []struct {
+		value            string
+		include, exclude []string
+	}{
+		{p.name, f.includeNames, f.excludeNames},
+		{p.id(), f.includeIDs, f.excludeIDs},
+		{p.driver, f.includeDrivers, f.excludeDrivers},
+	} {
+		if len(rule.include) != 0 && !matchAny(rule.include, rule.value) {
+			return false
+		}
+		if matchAny(rule.exclude, rule.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverPFs lists SR-IOV capable PFs under a base directory sorted by name.
+func discoverPFs(directory string) ([]pf, error) {
+	paths, err := filepath.Glob(filepath.Join(directory, sriovTotalvfsGlob))
+	if err != nil {
+		return nil, err
+	}
+	pfs := make([]pf, 0, len(paths))
+	for _, path := range paths {
+		p, err := readPF(directory, filepath.Base(filepath.Dir(filepath.Dir(path))))
+		if err != nil {
+			return nil, err
+		}
+		pfs = append(pfs, p)
+	}
+	return pfs, nil
+}
+
+// readPF reads PCI IDs and address, NUMA node, driver, link details and VFs numbers of a PF.
+func readPF(directory, name string) (pf, error) {
+	p := pf{name: name}
+	var err error
+	if p.totalvfs, err = readInt(fmt.Sprintf(filepath.Join(directory, sriovTotalvfsMask), name)); err != nil {
+		return p, err
+	}
+	if p.numvfs, err = readInt(fmt.Sprintf(filepath.Join(directory, sriovNumvfsMask), name)); err != nil {
+		return p, err
+	}
+	if p.vendor, err = readPCIID(fmt.Sprintf(filepath.Join(directory, pciVendorMask), name)); err != nil {
+		return p, err
+	}
+	if p.device, err = readPCIID(fmt.Sprintf(filepath.Join(directory, pciDeviceMask), name)); err != nil {
+		return p, err
+	}
+	driver, err := os.Readlink(fmt.Sprintf(filepath.Join(directory, pciDriverMask), name))
+	if err != nil && !os.IsNotExist(err) {
+		return p, err
+	}
+	if err == nil {
+		p.driver = filepath.Base(driver)
+	}
+	// speed can't be read while link is down
+	if speed, err := readInt(fmt.Sprintf(filepath.Join(directory, linkSpeedMask), name)); err == nil && speed > 0 {
+		p.speed = speed
+	}
+	if address, err := os.Readlink(fmt.Sprintf(filepath.Join(directory, pciDeviceLinkMask), name)); err == nil {
+		p.pciAddress = filepath.Base(address)
+	}
+	p.numaNode = nodestate.NUMANodeUnknown
+	if numaNode, err := readInt(fmt.Sprintf(filepath.Join(directory, numaNodeMask), name)); err == nil {
+		p.numaNode = int(numaNode)
+	}
+	p.linkDown = readLinkDown(directory, name)
+	p.firmware = readFirmwareVersion(name)
+	return p, nil
+}
+
+func readInt(path string) (int64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return value, nil
+}
+
+// readPCIID reads PCI ID such as 0x8086 and returns it without prefix, empty if file doesn't exist.
+func readPCIID(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"), nil
+}
+
+// inventory is a number of VFs of every resource configured on PFs and usable by pods.
+type inventory struct {
+	capacity    map[v1.ResourceName]int64
+	allocatable map[v1.ResourceName]int64
+	// pfs are details of PFs published as node labels
+	pfs []pf
+	// vfs of every PF published in SriovNodeState
+	vfs map[string][]vf
+}
+
+func newInventory() inventory {
+	inv := inventory{
+		capacity:    map[v1.ResourceName]int64{},
+		allocatable: map[v1.ResourceName]int64{},
+		vfs:         map[string][]vf{},
+	}
+	for _, res := range []v1.ResourceName{TotalVFsResource, NetdevVFsResource, DPDKVFsResource} {
+		inv.capacity[res] = 0
+		inv.allocatable[res] = 0
+	}
+	return inv
+}
+
+// addPF accounts VFs of a PF, inventory must be created with newInventory. Capacity is
+// a number of configured VFs, allocatable is a number of VFs bound to a driver and not
+// claimed by the host network namespace or a DPDK application. None of VFs are allocatable while link of the PF is down.
+func (inv *inventory) addPF(p pf, vfs []vf) {
+	var usable int64
+	for _, v := range vfs {
+		if v.driver == "" {
+			continue
+		}
+		mode := driverModeResource(v.driver)
+		inv.capacity[mode]++
+		if v.usable() && !p.linkDown {
+			inv.allocatable[mode]++
+			usable++
+		}
+	}
+	inv.pfs = append(inv.pfs, p)
+	inv.vfs[p.name] = vfs
+	inv.capacity[TotalVFsResource] += p.numvfs
+	inv.allocatable[TotalVFsResource] += usable
+	inv.capacity[pfResource(p.name)] = p.numvfs
+	inv.allocatable[pfResource(p.name)] = usable
+}
+
+func (inv inventory) resources() (capacity, allocatable v1.ResourceList) {
+	capacity, allocatable = v1.ResourceList{}, v1.ResourceList{}
+	for res, count := range inv.capacity {
+		capacity[res] = *resource.NewQuantity(count, resource.DecimalSI)
+	}
+	for res, count := range inv.allocatable {
+		allocatable[res] = *resource.NewQuantity(count, resource.DecimalSI)
+	}
+	return capacity, allocatable
+}
+
+// pfResource returns resource with VFs of a single PF.
+func pfResource(name string) v1.ResourceName {
+	return v1.ResourceName(fmt.Sprintf(pfResourceMask, name))
+}
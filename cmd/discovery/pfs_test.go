@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// writeFakePF creates sysfs entries of a PF and its VFs under dir.
+// VF in use has an interface which is up in the host namespace.
+func writeFakePF(t *testing.T, dir string, p pf, vfs ...vf) {
+	// devices with PCI addresses are links to PCI devices same as in sysfs
+	pciDevice := func(link, address string) {
+		if address == "" {
+			require.NoError(t, os.MkdirAll(link, 0755))
+			return
+		}
+		target := filepath.Join(dir, "sys/bus/pci/devices", address)
+		require.NoError(t, os.MkdirAll(target, 0755))
+		require.NoError(t, os.MkdirAll(filepath.Dir(link), 0755))
+		require.NoError(t, os.Symlink(target, link))
+	}
+	device := filepath.Join(dir, "sys/class/net", p.name, "device")
+	pciDevice(device, p.pciAddress)
+	for file, value := range map[string]string{
+		"sriov_totalvfs": fmt.Sprintf("%d\n", p.totalvfs),
+		"sriov_numvfs":   fmt.Sprintf("%d\n", p.numvfs),
+		"vendor":         "0x" + p.vendor + "\n",
+		"device":         "0x" + p.device + "\n",
+		"numa_node":      fmt.Sprintf("%d\n", p.numaNode),
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(device, file), []byte(value), 0644))
+	}
+	if p.linkDown {
+		operstate := filepath.Join(dir, "sys/class/net", p.name, "operstate")
+		require.NoError(t, ioutil.WriteFile(operstate, []byte("down\n"), 0644))
+	}
+	if p.speed != 0 {
+		speed := fmt.Sprintf("%d\n", p.speed)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sys/class/net", p.name, "speed"), []byte(speed), 0644))
+	}
+	// writable attributes are created empty, tests check values written by discovery
+	attribute := func(path string) {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			require.NoError(t, ioutil.WriteFile(path, nil, 0644))
+		}
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sys/bus/pci"), 0755))
+	attribute(filepath.Join(dir, pciDriversProbePath))
+	link := func(from, driver string) {
+		driverDir := filepath.Join(dir, "sys/bus/pci/drivers", driver)
+		require.NoError(t, os.MkdirAll(driverDir, 0755))
+		attribute(filepath.Join(driverDir, "unbind"))
+		require.NoError(t, os.Symlink(driverDir, filepath.Join(from, "driver")))
+	}
+	if p.driver != "" {
+		link(device, p.driver)
+	}
+	for i, v := range vfs {
+		virtfn := filepath.Join(device, fmt.Sprintf("virtfn%d", i))
+		pciDevice(virtfn, v.pciAddress)
+		attribute(filepath.Join(virtfn, "driver_override"))
+		if v.driverOverride != "" {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(virtfn, "driver_override"), []byte(v.driverOverride+"\n"), 0644))
+		}
+		if v.driver != "" {
+			link(virtfn, v.driver)
+		}
+		if v.netdev == "" {
+			continue
+		}
+		netdev := filepath.Join(virtfn, "net", v.netdev)
+		require.NoError(t, os.MkdirAll(netdev, 0755))
+		flags := "0x1002"
+		if v.inUse {
+			flags = "0x1003"
+		}
+		require.NoError(t, ioutil.WriteFile(filepath.Join(netdev, "flags"), []byte(flags+"\n"), 0644))
+		if v.mac != "" {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(netdev, "address"), []byte(v.mac+"\n"), 0644))
+		}
+	}
+}
+
+// fakeFirmware makes discovery report firmware versions of devices, it returns a function restoring ethtool.
+func fakeFirmware(versions map[string]string) func() {
+	original := readFirmwareVersion
+	readFirmwareVersion = func(name string) string {
+		return versions[name]
+	}
+	return func() {
+		readFirmwareVersion = original
+	}
+}
+
+// fakeVFConfig makes discovery report MAC and VLAN of VFs of devices, it returns a function restoring netlink.
+func fakeVFConfig(configs map[string]map[int]vfConfig) func() {
+	original := readVFConfig
+	readVFConfig = func(name string) (map[int]vfConfig, error) {
+		return configs[name], nil
+	}
+	return func() {
+		readVFConfig = original
+	}
+}
+
+func resourceCounts(resources v1.ResourceList) map[v1.ResourceName]int64 {
+	counts := map[v1.ResourceName]int64{}
+	for res, quantity := range resources {
+		counts[res] = quantity.Value()
+	}
+	return counts
+}
+
+func TestHostDPDKVFsAreNotAllocatable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sriov-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer fakeFirmware(nil)()
+	defer fakeVFConfig(nil)()
+	// the first VF is bound for pods with driver_override, the second one by a DPDK application of the host
+	writeFakePF(t, dir, pf{name: "ens1f0", numvfs: 3},
+		vf{driver: "vfio-pci", driverOverride: "vfio-pci"}, vf{driver: "vfio-pci"}, vf{driver: "iavf"})
+
+	inv, err := discoverAll(dir, pfFilter{})
+	require.NoError(t, err)
+	capacity, allocatable := inv.resources()
+	require.Equal(t, map[v1.ResourceName]int64{
+		TotalVFsResource:  3,
+		NetdevVFsResource: 1,
+		DPDKVFsResource:   2,
+		"totalvfs-ens1f0": 3,
+	}, resourceCounts(capacity))
+	require.Equal(t, map[v1.ResourceName]int64{
+		TotalVFsResource:  2,
+		NetdevVFsResource: 1,
+		DPDKVFsResource:   1,
+		"totalvfs-ens1f0": 2,
+	}, resourceCounts(allocatable))
+	require.True(t, nodeState("node-1", inv).Status.PFs[0].VFs[1].InUse)
+}
+
+func TestDiscoverAll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sriov-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer fakeFirmware(map[string]string{"ens1f0": "6.01 0x800034a4 1.1747.0"})()
+	defer fakeVFConfig(nil)()
+	intel := pf{name: "ens1f0", vendor: "8086", device: "1572", driver: "i40e", totalvfs: 8, numvfs: 2,
+		firmware: "6.01 0x800034a4 1.1747.0", speed: 10000, pciAddress: "0000:03:00.0", numaNode: 1}
+	mellanox := pf{name: "ens2f0", vendor: "15b3", device: "1017", driver: "mlx5_core", totalvfs: 4, numvfs: 1}
+	writeFakePF(t, dir, intel, vf{driver: "iavf", netdev: "ens1f0v0", inUse: true},
+		vf{driver: "vfio-pci", driverOverride: "vfio-pci"})
+	writeFakePF(t, dir, mellanox, vf{driver: "mlx5_core", netdev: "ens2f0v0"})
+	// interface without SR-IOV
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sys/class/net/lo"), 0755))
+
+	pfs, err := discoverPFs(dir)
+	require.NoError(t, err)
+	require.Equal(t, []pf{intel, mellanox}, pfs)
+
+	inv, err := discoverAll(dir, pfFilter{})
+	require.NoError(t, err)
+	require.Equal(t, []pf{intel, mellanox}, inv.pfs)
+	capacity, allocatable := inv.resources()
+	require.Equal(t, map[v1.ResourceName]int64{
+		TotalVFsResource:  3,
+		NetdevVFsResource: 2,
+		DPDKVFsResource:   1,
+		"totalvfs-ens1f0": 2,
+		"totalvfs-ens2f0": 1,
+	}, resourceCounts(capacity))
+	require.Equal(t, map[v1.ResourceName]int64{
+		TotalVFsResource:  2,
+		NetdevVFsResource: 1,
+		DPDKVFsResource:   1,
+		"totalvfs-ens1f0": 1,
+		"totalvfs-ens2f0": 1,
+	}, resourceCounts(allocatable))
+
+	inv, err = discoverAll(dir, pfFilter{excludeDrivers: []string{"mlx5*"}})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), inv.capacity[TotalVFsResource])
+	_, exists := inv.capacity["totalvfs-ens2f0"]
+	require.False(t, exists)
+
+	inv, err = discoverDevice(dir, "ens2f0")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), inv.allocatable[TotalVFsResource])
+}
+
+func TestPFFilter(t *testing.T) {
+	p := pf{name: "ens1f0", vendor: "8086", device: "1572", driver: "i40e"}
+	for i, tc := range []struct {
+		filter  pfFilter
+		matched bool
+	}{
+		{pfFilter{}, true},
+		{pfFilter{includeNames: []string{"eth*", "ens1*"}}, true},
+		{pfFilter{includeNames: []string{"eth*"}}, false},
+		{pfFilter{excludeNames: []string{"ens1f0"}}, false},
+		{pfFilter{includeIDs: []string{"8086:*"}}, true},
+		{pfFilter{includeIDs: []string{"15b3:*"}}, false},
+		{pfFilter{excludeIDs: []string{"8086:1572"}}, false},
+		{pfFilter{includeDrivers: []string{"i40e"}, excludeNames: []string{"ens2*"}}, true},
+		{pfFilter{includeDrivers: []string{"mlx5_core"}}, false},
+	} {
+		require.Equal(t, tc.matched, tc.filter.match(p), "case %d", i)
+	}
+	require.Error(t, pfFilter{includeIDs: []string{"[8086"}}.validate())
+}
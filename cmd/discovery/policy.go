@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+const (
+	// defaultPolicyKey is a key of the policy ConfigMap applied to nodes without their own key.
+	defaultPolicyKey = "default"
+
+	virtfnMask          = "sys/class/net/%s/device/virtfn%d"
+	pciDriversProbePath = "sys/bus/pci/drivers_probe"
+)
+
+// policy is a desired configuration of PFs of a node.
+type policy struct {
+	PFs []pfPolicy `json:"pfs"`
+}
+
+// pfPolicy is a desired configuration of a PF and its VFs.
+type pfPolicy struct {
+	// Name of the PF interface, e.g. ens1f0
+	Name string `json:"name"`
+	// NumVFs is a number of VFs to create, left as is if not set
+	NumVFs *int64 `json:"numVFs,omitempty"`
+	// Driver to bind VFs to, e.g. vfio-pci, left as is if empty
+	Driver string `json:"driver,omitempty"`
+}
+
+func parsePolicy(data string) (*policy, error) {
+	pol := &policy{}
+	if err := yaml.Unmarshal([]byte(data), pol); err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for i, pp := range pol.PFs {
+		switch {
+		case pp.Name == "":
+			return nil, fmt.Errorf("pf %d: name is required", i)
+		case names[pp.Name]:
+			return nil, fmt.Errorf("pf %d: duplicate name %q", i, pp.Name)
+		case pp.NumVFs != nil && *pp.NumVFs < 0:
+			return nil, fmt.Errorf("pf %d (%s): negative numVFs", i, pp.Name)
+		case strings.ContainsRune(pp.Driver, '/'):
+			return nil, fmt.Errorf("pf %d (%s): invalid driver %q", i, pp.Name, pp.Driver)
+		}
+		names[pp.Name] = true
+	}
+	return pol, nil
+}
+
+// splitPolicyName splits namespace/name of the policy ConfigMap.
+func splitPolicyName(policyName string) (namespace, name string, err error) {
+	parts := strings.Split(policyName, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("policy has to be namespace/name of a ConfigMap, got %q", policyName)
+	}
+	return parts[0], parts[1], nil
+}
+
+// readPolicy reads policy of a node from a ConfigMap, either under the node name or the default key.
+// Nil policy is returned if ConfigMap doesn't exist or has no policy for the node.
+func readPolicy(namespace, name, nodename string, client kubernetes.Interface) (*policy, error) {
+	configMap, err := client.Core().ConfigMaps(namespace).Get(name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Printf("Policy ConfigMap %s/%s doesn't exist\n", namespace, name)
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting policy ConfigMap %s/%s: %v", namespace, name, err)
+	}
+	key := nodename
+	data, exists := configMap.Data[key]
+	if !exists {
+		key = defaultPolicyKey
+		if data, exists = configMap.Data[key]; !exists {
+			return nil, nil
+		}
+	}
+	pol, err := parsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy %s of ConfigMap %s/%s: %v", key, namespace, name, err)
+	}
+	return pol, nil
+}
+
+// podAllocation is the part of an allocation recorded by the extender discovery needs.
+type podAllocation struct {
+	// Pool is the most specific resource VFs are accounted against, e.g. totalvfs-ens1f0
+	Pool v1.ResourceName `json:"pool"`
+	// PF is empty if the extender didn't choose one
+	PF string `json:"pf"`
+}
+
+// pf returns PF VFs of an allocation are taken from, empty if it isn't known.
+func (a podAllocation) pf() string {
+	if a.PF != "" {
+		return a.PF
+	}
+	if prefix := fmt.Sprintf(pfResourceMask, ""); strings.HasPrefix(string(a.Pool), prefix) {
+		return strings.TrimPrefix(string(a.Pool), prefix)
+	}
+	return ""
+}
+
+// allocatedPFs returns PFs with VFs allocated to pods of a node, as recorded on pods by the
+// extender, or claimed according to the last SriovNodeState, states can be nil. Every PF
+// is mapped to pods or the state holding its VFs. An empty name means that a pod has VFs
+// of an unknown PF, i.e. the extender didn't record a PF because it runs without node states.
+func allocatedPFs(nodename string, client kubernetes.Interface, states nodestate.Interface) (map[string][]string, error) {
+	allocated := map[string][]string{}
+	pods, err := client.Core().Pods(meta_v1.NamespaceAll).List(meta_v1.ListOptions{FieldSelector: "spec.nodeName=" + nodename})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods of a node %s: %v", nodename, err)
+	}
+	for _, pod := range pods.Items {
+		value, exists := pod.Annotations[nodestate.AllocationAnnotation]
+		if !exists || pod.Spec.NodeName != nodename || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		var alloc podAllocation
+		if err := json.Unmarshal([]byte(value), &alloc); err != nil {
+			log.Printf("Invalid %s annotation of pod %s/%s, its PF is unknown: %v\n",
+				nodestate.AllocationAnnotation, pod.Namespace, pod.Name, err)
+		}
+		pf := alloc.pf()
+		allocated[pf] = append(allocated[pf], "pod "+pod.Namespace+"/"+pod.Name)
+	}
+	if states == nil {
+		return allocated, nil
+	}
+	state, err := states.Get(nodename)
+	if errors.IsNotFound(err) {
+		return allocated, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting SriovNodeState of a node %s: %v", nodename, err)
+	}
+	for _, p := range state.Status.PFs {
+		for _, v := range p.VFs {
+			if v.InUse {
+				allocated[p.Name] = append(allocated[p.Name], "SriovNodeState")
+				break
+			}
+		}
+	}
+	return allocated, nil
+}
+
+// applyPolicy configures every PF of a policy under a base directory, PFs with allocated
+// VFs are skipped. Errors of a PF don't prevent configuration of others.
+func applyPolicy(directory string, pol *policy, allocated map[string][]string) error {
+	var errs []string
+	for _, pp := range pol.PFs {
+		if err := applyPFPolicy(directory, pp, allocated); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("error applying policy: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// applyPFPolicy sets number of VFs of a PF and binds them to a driver. PF isn't reconfigured
+// while any of its VFs is allocated or claimed by the host, so that VFs of running workloads
+// aren't destroyed. VFs already bound to the driver, e.g. by an earlier run, are not in use and
+// are skipped, so a partial bind converges. Pods with VFs of unknown PFs block every PF.
+func applyPFPolicy(directory string, pp pfPolicy, allocated map[string][]string) error {
+	p, err := readPF(directory, pp.Name)
+	if err != nil {
+		return fmt.Errorf("error reading device %s: %v", pp.Name, err)
+	}
+	if pp.NumVFs != nil && *pp.NumVFs > p.totalvfs {
+		return fmt.Errorf("device %s supports %d VFs, %d requested", p.name, p.totalvfs, *pp.NumVFs)
+	}
+	virtfnGlob := fmt.Sprintf(filepath.Join(directory, sriovVirtfnMask), p.name)
+	vfs, err := discoverVFs(virtfnGlob)
+	if err != nil {
+		return fmt.Errorf("error discovering VFs from %s: %v", virtfnGlob, err)
+	}
+	resize := pp.NumVFs != nil && *pp.NumVFs != p.numvfs
+	if !resize && len(vfsToBind(vfs, pp.Driver)) == 0 {
+		return nil
+	}
+	if holders := allocated[p.name]; len(holders) != 0 {
+		log.Printf("Device %s isn't reconfigured while its VFs are allocated to %s\n", p.name, strings.Join(holders, ", "))
+		return nil
+	}
+	if holders := allocated[""]; len(holders) != 0 {
+		log.Printf("Device %s isn't reconfigured while VFs of unknown PFs are allocated to %s, "+
+			"the extender records PFs of allocations only with --node-states\n", p.name, strings.Join(holders, ", "))
+		return nil
+	}
+	for _, v := range vfs {
+		if v.inUse {
+			log.Printf("Device %s isn't reconfigured while its VF %d is in use\n", p.name, v.index)
+			return nil
+		}
+	}
+	if resize {
+		numvfsPath := fmt.Sprintf(filepath.Join(directory, sriovNumvfsMask), p.name)
+		// kernel refuses to change a number of VFs unless they are removed first
+		if p.numvfs != 0 {
+			if err := writeSysfs(numvfsPath, "0"); err != nil {
+				return err
+			}
+		}
+		log.Printf("Creating %d VFs of device %s\n", *pp.NumVFs, p.name)
+		if err := writeSysfs(numvfsPath, strconv.FormatInt(*pp.NumVFs, 10)); err != nil {
+			return err
+		}
+		// new VFs are bound to default drivers
+		if vfs, err = discoverVFs(virtfnGlob); err != nil {
+			return fmt.Errorf("error discovering VFs from %s: %v", virtfnGlob, err)
+		}
+	}
+	for _, v := range vfsToBind(vfs, pp.Driver) {
+		log.Printf("Binding VF %d of device %s to driver %s\n", v.index, p.name, pp.Driver)
+		if err := bindVF(directory, fmt.Sprintf(filepath.Join(directory, virtfnMask), p.name, v.index), v, pp.Driver); err != nil {
+			return fmt.Errorf("error binding VF %d of device %s to driver %s: %v", v.index, p.name, pp.Driver, err)
+		}
+	}
+	return nil
+}
+
+// vfsToBind returns VFs which are not bound to a given driver, none if driver is empty.
+func vfsToBind(vfs []vf, driver string) []vf {
+	var unbound []vf
+	for _, v := range vfs {
+		if driver != "" && v.driver != driver {
+			unbound = append(unbound, v)
+		}
+	}
+	return unbound
+}
+
+// bindVF overrides driver of a VF, unbinds it from the current driver and probes it again,
+// so that only the overriding driver can claim it.
+func bindVF(directory, virtfn string, v vf, driver string) error {
+	if v.pciAddress == "" {
+		return fmt.Errorf("unknown PCI address of %s", virtfn)
+	}
+	if err := writeSysfs(filepath.Join(virtfn, "driver_override"), driver); err != nil {
+		return err
+	}
+	if v.driver != "" {
+		if err := writeSysfs(filepath.Join(virtfn, "driver", "unbind"), v.pciAddress); err != nil {
+			return err
+		}
+	}
+	return writeSysfs(filepath.Join(directory, pciDriversProbePath), v.pciAddress)
+}
+
+// writeSysfs writes a value to an existing sysfs attribute.
+func writeSysfs(path, value string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing %s to %s: %v", value, path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
+
+func TestParsePolicy(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected *policy
+		error    bool
+	}{
+		{
+			data: "pfs:\n- name: ens1f0\n  numVFs: 8\n  driver: vfio-pci\n- name: ens1f1\n  numVFs: 0\n",
+			expected: &policy{PFs: []pfPolicy{
+				{Name: "ens1f0", NumVFs: int64Ptr(8), Driver: "vfio-pci"},
+				{Name: "ens1f1", NumVFs: int64Ptr(0)},
+			}},
+		},
+		{
+			data:     `{"pfs": [{"name": "ens1f0", "driver": "iavf"}]}`,
+			expected: &policy{PFs: []pfPolicy{{Name: "ens1f0", Driver: "iavf"}}},
+		},
+		{data: "pfs:\n- numVFs: 8\n", error: true},
+		{data: "pfs:\n- name: ens1f0\n- name: ens1f0\n", error: true},
+		{data: "pfs:\n- name: ens1f0\n  numVFs: -1\n", error: true},
+		{data: "pfs:\n- name: ens1f0\n  driver: ../vfio-pci\n", error: true},
+		{data: "pfs: ens1f0", error: true},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			pol, err := parsePolicy(tc.data)
+			if tc.error {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, pol)
+		})
+	}
+}
+
+func TestReadPolicy(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "kube-system", Name: "sriov-policy"},
+		Data: map[string]string{
+			"node-1":  "pfs:\n- name: ens1f0\n  numVFs: 4\n",
+			"default": "pfs:\n- name: ens1f0\n  numVFs: 2\n",
+			"node-3":  "pfs: ens1f0",
+		},
+	})
+	pol, err := readPolicy("kube-system", "sriov-policy", "node-1", client)
+	require.NoError(t, err)
+	require.Equal(t, &policy{PFs: []pfPolicy{{Name: "ens1f0", NumVFs: int64Ptr(4)}}}, pol)
+
+	pol, err = readPolicy("kube-system", "sriov-policy", "node-2", client)
+	require.NoError(t, err)
+	require.Equal(t, &policy{PFs: []pfPolicy{{Name: "ens1f0", NumVFs: int64Ptr(2)}}}, pol)
+
+	_, err = readPolicy("kube-system", "sriov-policy", "node-3", client)
+	require.Error(t, err)
+
+	pol, err = readPolicy("default", "sriov-policy", "node-1", client)
+	require.NoError(t, err)
+	require.Nil(t, pol)
+}
+
+func readSysfs(t *testing.T, dir, path string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, path))
+	require.NoError(t, err)
+	return strings.TrimSpace(string(data))
+}
+
+func TestApplyPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sriov-discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer fakeFirmware(nil)()
+	writeFakePF(t, dir, pf{name: "ens1f0", totalvfs: 8, numvfs: 3},
+		vf{pciAddress: "0000:03:02.0", driver: "iavf", netdev: "ens1f0v0"},
+		vf{pciAddress: "0000:03:02.1"},
+		vf{pciAddress: "0000:03:02.2"},
+	)
+	writeFakePF(t, dir, pf{name: "ens2f0", totalvfs: 8, numvfs: 1},
+		vf{pciAddress: "0000:04:02.0", driver: "iavf", netdev: "ens2f0v0"})
+	writeFakePF(t, dir, pf{name: "ens3f0", totalvfs: 8, numvfs: 1},
+		vf{pciAddress: "0000:05:02.0", driver: "iavf", netdev: "ens3f0v0", inUse: true})
+	writeFakePF(t, dir, pf{name: "ens4f0", totalvfs: 8, numvfs: 1})
+	// interface of the VF was moved to a pod namespace
+	writeFakePF(t, dir, pf{name: "ens6f0", totalvfs: 8, numvfs: 1},
+		vf{pciAddress: "0000:07:02.0", driver: "iavf"})
+	// the first VF is bound to a DPDK driver by the host
+	writeFakePF(t, dir, pf{name: "ens7f0", totalvfs: 8, numvfs: 2},
+		vf{pciAddress: "0000:08:02.0", driver: "vfio-pci"},
+		vf{pciAddress: "0000:08:02.1", driver: "iavf", netdev: "ens7f0v1"})
+	writeFakePF(t, dir, pf{name: "ens8f0", totalvfs: 8, numvfs: 1},
+		vf{pciAddress: "0000:09:02.0", driver: "iavf", netdev: "ens8f0v0"})
+	// an earlier run bound only the first VF
+	writeFakePF(t, dir, pf{name: "ens9f0", totalvfs: 8, numvfs: 2},
+		vf{pciAddress: "0000:0a:02.0", driver: "vfio-pci", driverOverride: "vfio-pci"},
+		vf{pciAddress: "0000:0a:02.1", driver: "iavf", netdev: "ens9f0v1"})
+	// an earlier run bound all VFs
+	writeFakePF(t, dir, pf{name: "ens10f0", totalvfs: 8, numvfs: 2},
+		vf{pciAddress: "0000:0b:02.0", driver: "vfio-pci", driverOverride: "vfio-pci"},
+		vf{pciAddress: "0000:0b:02.1", driver: "vfio-pci", driverOverride: "vfio-pci"})
+
+	err = applyPolicy(dir, &policy{PFs: []pfPolicy{
+		// VFs bound by the policy don't prevent reconfiguration
+		{Name: "ens9f0", Driver: "vfio-pci"},
+		{Name: "ens10f0", NumVFs: int64Ptr(4), Driver: "vfio-pci"},
+		{Name: "ens1f0", NumVFs: int64Ptr(3), Driver: "vfio-pci"},
+		{Name: "ens2f0", NumVFs: int64Ptr(4)},
+		// VF in use prevents reconfiguration
+		{Name: "ens3f0", NumVFs: int64Ptr(4), Driver: "vfio-pci"},
+		{Name: "ens4f0", NumVFs: int64Ptr(16)},
+		{Name: "ens5f0", NumVFs: int64Ptr(1)},
+		{Name: "ens6f0", NumVFs: int64Ptr(4)},
+		{Name: "ens7f0", Driver: "vfio-pci"},
+		// VFs allocated to a pod prevent reconfiguration
+		{Name: "ens8f0", NumVFs: int64Ptr(4)},
+	}}, map[string][]string{"ens8f0": {"pod default/first"}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "device ens4f0 supports 8 VFs, 16 requested")
+	require.Contains(t, err.Error(), "error reading device ens5f0")
+
+	// VFs bound to other drivers or unbound are overridden and probed again
+	require.Equal(t, "3", readSysfs(t, dir, "sys/class/net/ens1f0/device/sriov_numvfs"))
+	require.Equal(t, "vfio-pci", readSysfs(t, dir, "sys/class/net/ens1f0/device/virtfn0/driver_override"))
+	require.Equal(t, "vfio-pci", readSysfs(t, dir, "sys/class/net/ens1f0/device/virtfn1/driver_override"))
+	require.Equal(t, "vfio-pci", readSysfs(t, dir, "sys/class/net/ens1f0/device/virtfn2/driver_override"))
+	require.Equal(t, "0000:03:02.0", readSysfs(t, dir, "sys/bus/pci/drivers/iavf/unbind"))
+	require.Equal(t, "0000:03:02.2", readSysfs(t, dir, pciDriversProbePath))
+
+	require.Equal(t, "4", readSysfs(t, dir, "sys/class/net/ens2f0/device/sriov_numvfs"))
+	require.Equal(t, "1", readSysfs(t, dir, "sys/class/net/ens3f0/device/sriov_numvfs"))
+	require.Equal(t, "", readSysfs(t, dir, "sys/class/net/ens3f0/device/virtfn0/driver_override"))
+	require.Equal(t, "1", readSysfs(t, dir, "sys/class/net/ens4f0/device/sriov_numvfs"))
+	require.Equal(t, "4", readSysfs(t, dir, "sys/class/net/ens6f0/device/sriov_numvfs"))
+	require.Equal(t, "", readSysfs(t, dir, "sys/class/net/ens7f0/device/virtfn1/driver_override"))
+	require.Equal(t, "1", readSysfs(t, dir, "sys/class/net/ens8f0/device/sriov_numvfs"))
+	require.Equal(t, "vfio-pci", readSysfs(t, dir, "sys/class/net/ens9f0/device/virtfn1/driver_override"))
+	require.Equal(t, "4", readSysfs(t, dir, "sys/class/net/ens10f0/device/sriov_numvfs"))
+
+	// VFs of an unknown PF are allocated
+	err = applyPolicy(dir, &policy{PFs: []pfPolicy{{Name: "ens2f0", NumVFs: int64Ptr(2)}}},
+		map[string][]string{"": {"pod default/second"}})
+	require.NoError(t, err)
+	require.Equal(t, "4", readSysfs(t, dir, "sys/class/net/ens2f0/device/sriov_numvfs"))
+}
+
+func TestAllocatedPFs(t *testing.T) {
+	makePod := func(name, node string, phase v1.PodPhase, allocation string) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: "default", Name: name, Annotations: map[string]string{}},
+			Spec:       v1.PodSpec{NodeName: node},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+		if allocation != "" {
+			pod.Annotations[nodestate.AllocationAnnotation] = allocation
+		}
+		return pod
+	}
+	client := fake.NewSimpleClientset(
+		makePod("running", "node-1", v1.PodRunning, `{"node": "node-1", "pf": "ens1f0", "count": 1}`),
+		makePod("finished", "node-1", v1.PodSucceeded, `{"node": "node-1", "pf": "ens2f0", "count": 1}`),
+		makePod("other", "node-2", v1.PodRunning, `{"node": "node-2", "pf": "ens3f0", "count": 1}`),
+		makePod("plain", "node-1", v1.PodRunning, ""),
+		makePod("pool", "node-1", v1.PodRunning, `{"node": "node-1", "pool": "totalvfs-ens6f0", "count": 1}`),
+	)
+	state := nodestate.SriovNodeState{Status: nodestate.SriovNodeStateStatus{PFs: []nodestate.PF{
+		{Name: "ens4f0", VFs: []nodestate.VF{{Index: 0}, {Index: 1, InUse: true}}},
+		{Name: "ens5f0", VFs: []nodestate.VF{{Index: 0}}},
+	}}}
+	state.Name = "node-1"
+	allocated, err := allocatedPFs("node-1", client, nodestate.NewFake(state))
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"ens1f0": {"pod default/running"},
+		"ens4f0": {"SriovNodeState"},
+		"ens6f0": {"pod default/pool"},
+	}, allocated)
+
+	// PF of an allocation from a pool of all PFs is unknown
+	client = fake.NewSimpleClientset(makePod("unknown", "node-1", v1.PodPending, `{"node": "node-1", "pool": "dpdkvfs", "count": 1}`))
+	allocated, err = allocatedPFs("node-1", client, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"": {"pod default/unknown"}}, allocated)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// backoffFactor multiplies a pause after every consecutive failure.
+	backoffFactor = 2
+	// backoffJitter is the largest fraction of a pause added randomly, so that discovery
+	// of all nodes doesn't hit API server at once after an outage.
+	backoffJitter = 0.5
+)
+
+// status is an outcome of the last discovery attempts.
+type status struct {
+	sync.Mutex
+	LastSuccess         time.Time `json:"lastSuccess"`
+	LastFailure         time.Time `json:"lastFailure"`
+	LastError           string    `json:"lastError,omitempty"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	// unhealthyAfter is a number of consecutive failures after which discovery is unhealthy
+	unhealthyAfter int
+}
+
+func (s *status) succeeded(now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	s.LastSuccess = now
+	s.LastError = ""
+	s.ConsecutiveFailures = 0
+}
+
+func (s *status) failed(now time.Time, err error) {
+	s.Lock()
+	defer s.Unlock()
+	s.LastFailure = now
+	s.LastError = err.Error()
+	s.ConsecutiveFailures++
+}
+
+// ServeHTTP reports status as JSON with 503 status code once discovery is unhealthy.
+func (s *status) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	data, err := json.Marshal(s)
+	healthy := s.unhealthyAfter <= 0 || s.ConsecutiveFailures < s.unhealthyAfter
+	s.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if _, err := w.Write(data); err != nil {
+		log.Printf("error writing response body: %v", err)
+	}
+}
+
+// backoff is a jittered exponential pause between failed attempts.
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+	current time.Duration
+}
+
+func (b *backoff) next() time.Duration {
+	if b.current == 0 {
+		b.current = b.initial
+	} else if b.current *= backoffFactor; b.current > b.max {
+		b.current = b.max
+	}
+	return wait.Jitter(b.current, backoffJitter)
+}
+
+func (b *backoff) reset() {
+	b.current = 0
+}
+
+// runner calls discovery every interval, or once if interval is zero. Failed attempts are
+// retried after a backoff. In periodic mode discovery never gives up, otherwise an error
+// is returned once attempts are exhausted.
+type runner struct {
+	interval time.Duration
+	attempts int
+	backoff  *backoff
+	status   *status
+	after    func(time.Duration) <-chan time.Time
+}
+
+func (r *runner) run(stopCh <-chan struct{}, f func() error) error {
+	failures := 0
+	for {
+		select {
+		case <-stopCh:
+			return nil
+		default:
+		}
+		var pause time.Duration
+		if err := f(); err == nil {
+			r.status.succeeded(time.Now())
+			r.backoff.reset()
+			failures = 0
+			if r.interval == 0 {
+				return nil
+			}
+			pause = r.interval
+		} else {
+			r.status.failed(time.Now(), err)
+			failures++
+			if r.interval == 0 && failures >= r.attempts {
+				return err
+			}
+			pause = r.backoff.next()
+			log.Printf("Discovery failed: %v. Retrying in %v\n", err, pause)
+		}
+		select {
+		case <-r.after(pause):
+		case <-stopCh:
+			return nil
+		}
+	}
+}
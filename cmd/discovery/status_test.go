@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	b := &backoff{initial: time.Second, max: 3 * time.Second}
+	for i, base := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		pause := b.next()
+		require.True(t, pause >= base && pause <= base+base/2, "case %d: %v", i, pause)
+	}
+	b.reset()
+	require.True(t, b.next() < 2*time.Second)
+}
+
+func TestRunner(t *testing.T) {
+	var pauses []time.Duration
+	after := func(d time.Duration) <-chan time.Time {
+		pauses = append(pauses, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Now()
+		return ch
+	}
+	errTransient := fmt.Errorf("api server is unavailable")
+
+	// one shot discovery gives up once attempts are exhausted
+	r := &runner{attempts: 3, backoff: &backoff{initial: time.Second, max: time.Minute},
+		status: &status{}, after: after}
+	calls := 0
+	err := r.run(nil, func() error {
+		calls++
+		return errTransient
+	})
+	require.Equal(t, errTransient, err)
+	require.Equal(t, 3, calls)
+	require.Len(t, pauses, 2)
+	require.Equal(t, 3, r.status.ConsecutiveFailures)
+	require.Equal(t, errTransient.Error(), r.status.LastError)
+
+	// periodic discovery keeps going after failures and resets backoff after a success
+	pauses = nil
+	r = &runner{interval: time.Hour, attempts: 1, backoff: &backoff{initial: time.Second, max: time.Minute},
+		status: &status{}, after: after}
+	stopCh := make(chan struct{})
+	results := []error{errTransient, errTransient, nil, errTransient}
+	err = r.run(stopCh, func() error {
+		result := results[0]
+		results = results[1:]
+		if len(results) == 0 {
+			close(stopCh)
+		}
+		return result
+	})
+	require.NoError(t, err)
+	require.Len(t, pauses, 4)
+	require.True(t, pauses[0] < 2*time.Second)
+	require.True(t, pauses[1] >= 2*time.Second)
+	require.Equal(t, time.Hour, pauses[2])
+	require.True(t, pauses[3] < 2*time.Second, "backoff should be reset after a success")
+	require.Equal(t, 1, r.status.ConsecutiveFailures)
+	require.False(t, r.status.LastSuccess.IsZero())
+}
+
+func TestStatusHandler(t *testing.T) {
+	st := &status{unhealthyAfter: 2}
+	srv := httptest.NewServer(st)
+	defer srv.Close()
+	type reportedStatus struct {
+		LastSuccess         time.Time
+		LastError           string
+		ConsecutiveFailures int
+	}
+	get := func() (int, reportedStatus) {
+		resp, err := http.Get(srv.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var reported reportedStatus
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&reported))
+		return resp.StatusCode, reported
+	}
+	code, _ := get()
+	require.Equal(t, http.StatusOK, code)
+
+	st.failed(time.Now(), fmt.Errorf("first"))
+	code, _ = get()
+	require.Equal(t, http.StatusOK, code)
+	st.failed(time.Now(), fmt.Errorf("second"))
+	code, reported := get()
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "second", reported.LastError)
+	require.Equal(t, 2, reported.ConsecutiveFailures)
+
+	st.succeeded(time.Now())
+	code, reported = get()
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, reported.LastError)
+	require.False(t, reported.LastSuccess.IsZero())
+}
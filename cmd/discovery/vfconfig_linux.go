@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Attributes of RTM_NEWLINK in linux/if_link.h and linux/rtnetlink.h.
+const (
+	iflaVFInfoList = 22
+	iflaExtMask    = 29
+	iflaVFInfo     = 1
+	iflaVFMAC      = 1
+	iflaVFVLAN     = 2
+	rtextFilterVF  = 1
+)
+
+// readVFConfig returns MAC and VLAN the PF driver configured for every VF as ip link show reports them.
+// They are not exposed in sysfs, so they are read from the host rather than --directory.
+var readVFConfig = netlinkVFConfig
+
+func netlinkVFConfig(name string) (map[int]vfConfig, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	defer syscall.Close(fd)
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, os.NewSyscallError("bind", err)
+	}
+	if err := syscall.Sendto(fd, linkRequest(iface.Index), 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, os.NewSyscallError("sendto", err)
+	}
+	buf := make([]byte, 64*os.Getpagesize())
+	n, _, err := syscall.Recvfrom(fd, buf, 0)
+	if err != nil {
+		return nil, os.NewSyscallError("recvfrom", err)
+	}
+	msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+	if err != nil {
+		return nil, err
+	}
+	for i := range msgs {
+		switch msgs[i].Header.Type {
+		case syscall.NLMSG_ERROR:
+			if errno := -int32(nativeUint32(msgs[i].Data)); errno != 0 {
+				return nil, fmt.Errorf("error getting link %s: %v", name, syscall.Errno(errno))
+			}
+		case syscall.RTM_NEWLINK:
+			attrs, err := syscall.ParseNetlinkRouteAttr(&msgs[i])
+			if err != nil {
+				return nil, err
+			}
+			for _, attr := range attrs {
+				if attr.Attr.Type == iflaVFInfoList {
+					return parseVFInfoList(attr.Value), nil
+				}
+			}
+			return map[int]vfConfig{}, nil
+		}
+	}
+	return nil, fmt.Errorf("no reply for link %s", name)
+}
+
+// linkRequest is RTM_GETLINK of a single link asking for VFs info.
+func linkRequest(index int) []byte {
+	const length = syscall.NLMSG_HDRLEN + syscall.SizeofIfInfomsg + syscall.SizeofRtAttr + 4
+	req := make([]byte, length)
+	*(*syscall.NlMsghdr)(unsafe.Pointer(&req[0])) = syscall.NlMsghdr{
+		Len: length, Type: syscall.RTM_GETLINK, Flags: syscall.NLM_F_REQUEST, Seq: 1,
+	}
+	*(*syscall.IfInfomsg)(unsafe.Pointer(&req[syscall.NLMSG_HDRLEN])) = syscall.IfInfomsg{
+		Family: syscall.AF_UNSPEC, Index: int32(index),
+	}
+	attr := syscall.NLMSG_HDRLEN + syscall.SizeofIfInfomsg
+	*(*syscall.RtAttr)(unsafe.Pointer(&req[attr])) = syscall.RtAttr{Len: syscall.SizeofRtAttr + 4, Type: iflaExtMask}
+	*(*uint32)(unsafe.Pointer(&req[attr+syscall.SizeofRtAttr])) = rtextFilterVF
+	return req
+}
+
+// parseVFInfoList reads nested IFLA_VF_INFO attributes.
+func parseVFInfoList(data []byte) map[int]vfConfig {
+	configs := map[int]vfConfig{}
+	for _, info := range parseAttrs(data) {
+		if info.Attr.Type != iflaVFInfo {
+			continue
+		}
+		index, config := -1, vfConfig{}
+		for _, attr := range parseAttrs(info.Value) {
+			switch {
+			// struct ifla_vf_mac is u32 vf and u8 mac[32]
+			case attr.Attr.Type == iflaVFMAC && len(attr.Value) >= 10:
+				index = int(nativeUint32(attr.Value))
+				if mac := net.HardwareAddr(attr.Value[4:10]); !isZero(mac) {
+					config.mac = mac.String()
+				}
+			// struct ifla_vf_vlan is u32 vf, u32 vlan and u32 qos
+			case attr.Attr.Type == iflaVFVLAN && len(attr.Value) >= 8:
+				index = int(nativeUint32(attr.Value))
+				config.vlan = int(nativeUint32(attr.Value[4:]))
+			}
+		}
+		if index >= 0 {
+			configs[index] = config
+		}
+	}
+	return configs
+}
+
+func parseAttrs(data []byte) []syscall.NetlinkRouteAttr {
+	var attrs []syscall.NetlinkRouteAttr
+	for len(data) >= syscall.SizeofRtAttr {
+		header := *(*syscall.RtAttr)(unsafe.Pointer(&data[0]))
+		if int(header.Len) < syscall.SizeofRtAttr || int(header.Len) > len(data) {
+			break
+		}
+		attrs = append(attrs, syscall.NetlinkRouteAttr{Attr: header, Value: data[syscall.SizeofRtAttr:header.Len]})
+		aligned := (int(header.Len) + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if aligned > len(data) {
+			break
+		}
+		data = data[aligned:]
+	}
+	return attrs
+}
+
+func nativeUint32(data []byte) uint32 {
+	if len(data) < 4 {
+		return 0
+	}
+	return *(*uint32)(unsafe.Pointer(&data[0]))
+}
+
+func isZero(mac net.HardwareAddr) bool {
+	for _, b := range mac {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
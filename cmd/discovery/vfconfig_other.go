@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// readVFConfig returns MAC and VLAN the PF driver configured for every VF, it isn't supported outside of linux.
+var readVFConfig = func(name string) (map[int]vfConfig, error) {
+	return nil, fmt.Errorf("VF configuration can't be read on %s", runtime.GOOS)
+}
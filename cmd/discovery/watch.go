@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log"
+	"reflect"
+	"time"
+)
+
+// watcher publishes inventory once it changes. It rediscovers VFs after link events and
+// every poll interval, since not every change of sysfs is visible as a link event.
+// Bursts of events, e.g. while VFs are created, are coalesced by the debounce.
+type watcher struct {
+	discover     func() (inventory, error)
+	publish      func(inventory) error
+	pollInterval time.Duration
+	debounce     time.Duration
+	backoff      *backoff
+	status       *status
+	after        func(time.Duration) <-chan time.Time
+}
+
+func (w *watcher) run(events <-chan struct{}, stopCh <-chan struct{}) {
+	var published *inventory
+	poll := w.after(w.pollInterval)
+	resync := w.after(0)
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-events:
+			resync = w.after(w.debounce)
+		case <-poll:
+			poll = w.after(w.pollInterval)
+			if resync == nil {
+				resync = w.after(0)
+			}
+		case <-resync:
+			resync = nil
+			inv, err := w.discover()
+			if err == nil && published != nil && reflect.DeepEqual(inv, *published) {
+				w.status.succeeded(time.Now())
+				continue
+			}
+			if err == nil {
+				err = w.publish(inv)
+			}
+			if err != nil {
+				w.status.failed(time.Now(), err)
+				pause := w.backoff.next()
+				log.Printf("Discovery failed: %v. Retrying in %v\n", err, pause)
+				resync = w.after(pause)
+				continue
+			}
+			log.Println("Inventory of VFs published")
+			published = &inv
+			w.status.succeeded(time.Now())
+			w.backoff.reset()
+		}
+	}
+}
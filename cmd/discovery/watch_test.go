@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInventory holds inventory discovered and published by a watcher under test.
+type fakeInventory struct {
+	sync.Mutex
+	current    inventory
+	discovered int
+	published  []inventory
+	publishErr error
+}
+
+func (f *fakeInventory) discover() (inventory, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.discovered++
+	return f.current, nil
+}
+
+func (f *fakeInventory) publish(inv inventory) error {
+	f.Lock()
+	defer f.Unlock()
+	if f.publishErr != nil {
+		err := f.publishErr
+		f.publishErr = nil
+		return err
+	}
+	f.published = append(f.published, inv)
+	return nil
+}
+
+func (f *fakeInventory) counts() (int, int) {
+	f.Lock()
+	defer f.Unlock()
+	return f.discovered, len(f.published)
+}
+
+func (f *fakeInventory) set(total int64, publishErr error) {
+	f.Lock()
+	defer f.Unlock()
+	f.current = newInventory()
+	f.current.capacity[TotalVFsResource] = total
+	f.publishErr = publishErr
+}
+
+func waitFor(t *testing.T, f func() error) {
+	var err error
+	for i := 0; i < 200; i++ {
+		if err = f(); err == nil {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.NoError(t, err)
+}
+
+func expectCounts(f *fakeInventory, discovered, published int) func() error {
+	return func() error {
+		if d, p := f.counts(); d != discovered || p != published {
+			return fmt.Errorf("expected %d discovered and %d published, got %d and %d", discovered, published, d, p)
+		}
+		return nil
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	fake := &fakeInventory{}
+	fake.set(4, nil)
+	w := &watcher{
+		discover:     fake.discover,
+		publish:      fake.publish,
+		pollInterval: time.Hour,
+		debounce:     20 * time.Millisecond,
+		backoff:      &backoff{initial: time.Millisecond, max: time.Millisecond},
+		status:       &status{},
+		after:        time.After,
+	}
+	events := make(chan struct{})
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go w.run(events, stopCh)
+
+	// inventory is published on start
+	waitFor(t, expectCounts(fake, 1, 1))
+
+	// burst of events is debounced and unchanged inventory isn't published
+	for i := 0; i < 3; i++ {
+		events <- struct{}{}
+	}
+	waitFor(t, expectCounts(fake, 2, 1))
+
+	// failed publish is retried after a backoff
+	fake.set(8, fmt.Errorf("api server is unavailable"))
+	events <- struct{}{}
+	waitFor(t, expectCounts(fake, 4, 2))
+	fake.Lock()
+	require.Equal(t, int64(8), fake.published[1].capacity[TotalVFsResource])
+	fake.Unlock()
+	waitFor(t, func() error {
+		w.status.Lock()
+		defer w.status.Unlock()
+		if w.status.ConsecutiveFailures != 0 || w.status.LastFailure.IsZero() {
+			return fmt.Errorf("failure should be recorded and cleared by the retry: %d", w.status.ConsecutiveFailures)
+		}
+		return nil
+	})
+}
+
+func TestWatcherPolls(t *testing.T) {
+	fake := &fakeInventory{}
+	fake.set(4, nil)
+	w := &watcher{
+		discover:     fake.discover,
+		publish:      fake.publish,
+		pollInterval: 10 * time.Millisecond,
+		debounce:     time.Hour,
+		backoff:      &backoff{initial: time.Millisecond, max: time.Millisecond},
+		status:       &status{},
+		after:        time.After,
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go w.run(nil, stopCh)
+	waitFor(t, expectCounts(fake, 1, 1))
+	fake.set(2, nil)
+	waitFor(t, func() error {
+		if _, published := fake.counts(); published != 2 {
+			return fmt.Errorf("changed inventory wasn't published")
+		}
+		return nil
+	})
+
+	// polls rediscover unchanged inventory without publishing it
+	discovered, _ := fake.counts()
+	waitFor(t, func() error {
+		if d, _ := fake.counts(); d < discovered+3 {
+			return fmt.Errorf("expected more polls, got %d discoveries", d)
+		}
+		return nil
+	})
+	_, published := fake.counts()
+	require.Equal(t, 2, published)
+}
@@ -3,29 +3,61 @@ package main
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/Mirantis/sriov-scheduler/pkg/extender"
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
 	"github.com/spf13/pflag"
 )
 
 type options struct {
-	listen           string
-	kubeconfig       string
-	promisesInterval time.Duration
+	listen            string
+	grpcListen        string
+	kubeconfig        string
+	promisesInterval  time.Duration
+	reconcileInterval time.Duration
+	readyTimeout      time.Duration
+	multus            bool
+	nodeStates        bool
+	config            string
+	security          extender.SecurityOptions
 }
 
 func (o *options) register() {
 	pflag.StringVarP(&o.listen, "listen", "l", ":8989", "Socket to listen on.")
+	pflag.StringVar(&o.grpcListen, "grpc-listen", "", "Socket to serve gRPC API on, disabled if empty.")
 	pflag.StringVar(&o.kubeconfig, "kubeconfig", "", "Kubernetes config file.")
 	pflag.DurationVarP(
 		&o.promisesInterval, "promises-interval", "p", 10*time.Second,
 		"Defines how long SR-IOV VFs will be promised to a particular pod.")
+	pflag.DurationVar(
+		&o.reconcileInterval, "reconcile-interval", time.Minute,
+		"Defines how often allocated SR-IOV VFs will be verified against pods known to kubelet.")
+	pflag.DurationVar(
+		&o.readyTimeout, "ready-timeout", 2*time.Minute,
+		"Defines how long pod informer can go without updates before extender reports it is not ready.")
+	pflag.BoolVar(
+		&o.multus, "multus", false,
+		"Resolve networks from multus annotation using NetworkAttachmentDefinitions.")
+	pflag.BoolVar(
+		&o.nodeStates, "node-states", false,
+		"Filter pods requesting particular PFs or NUMA nodes by SriovNodeStates written by discovery.")
+	pflag.StringVarP(&o.config, "config", "c", "", "Extender configuration file.")
+	pflag.StringVar(
+		&o.security.CertFile, "tls-cert-file", "",
+		"Serve HTTPS with this certificate, it is reloaded once changed.")
+	pflag.StringVar(&o.security.KeyFile, "tls-private-key-file", "", "Private key of the TLS certificate.")
+	pflag.StringVar(
+		&o.security.ClientCAFile, "client-ca-file", "",
+		"Require client certificates signed by one of CAs in this bundle.")
+	pflag.StringVar(&o.security.TokenFile, "token-file", "", "Require a bearer token stored in this file.")
 }
 
 func (o *options) parse() {
@@ -39,8 +71,20 @@ func (o *options) registerAndParse() {
 
 func main() {
 	log.SetOutput(os.Stderr)
+	if len(os.Args) > 1 && os.Args[1] == stateCommand {
+		runState(os.Args[2:])
+		return
+	}
 	opts := new(options)
 	opts.registerAndParse()
+	extConfig := &extender.Config{}
+	if opts.config != "" {
+		log.Printf("Using extender config %s\n", opts.config)
+		var err error
+		if extConfig, err = extender.LoadConfig(opts.config); err != nil {
+			log.Fatal(err)
+		}
+	}
 	log.Printf("Using kubernetes config %s\n", opts.kubeconfig)
 	config, err := clientcmd.BuildConfigFromFlags("", opts.kubeconfig)
 	if err != nil {
@@ -52,13 +96,64 @@ func main() {
 	}
 	stopCh := make(chan struct{})
 	ext := extender.NewExtender(client)
+	ext.SetReadyTimeout(opts.readyTimeout)
+	ext.SetEventRecorder(extender.NewEventRecorder(client))
+	if err := ext.SetAPIVersion(extConfig.APIVersion); err != nil {
+		log.Fatal(err)
+	}
+	synced := []func() bool{}
+	selectors := []extender.Selector{extender.ResourceSelector}
+	if opts.multus {
+		attachments, err := extender.NewNetworkAttachmentsInformer(config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go attachments.Run(stopCh)
+		synced = append(synced, attachments.HasSynced)
+		selectors = append(selectors, extender.MultusSelector(attachments))
+		ext.AddPools(attachments.Pools)
+	}
+	if opts.nodeStates {
+		states, err := nodestate.NewForConfig(config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		informer := nodestate.NewInformer(states)
+		go informer.Run(stopCh)
+		synced = append(synced, informer.HasSynced)
+		ext.SetNodeStates(informer)
+	}
+	if len(extConfig.Rules) != 0 {
+		var namespaceLabels extender.NamespaceLabels
+		if extConfig.NeedsNamespaces() {
+			var namespacesCtl cache.Controller
+			namespaceLabels, namespacesCtl = extender.NewNamespaceInformer(client)
+			go namespacesCtl.Run(stopCh)
+			synced = append(synced, namespacesCtl.HasSynced)
+		}
+		rules, err := extender.RulesSelector(extConfig.Rules, namespaceLabels)
+		if err != nil {
+			log.Fatal(err)
+		}
+		selectors = append(selectors, rules)
+		ext.AddPools(extConfig.Pools)
+	} else {
+		selectors = append(selectors, extender.NetworkSelector)
+	}
+	ext.SetSelector(extender.FallbackSelector(selectors...))
 	ctl := ext.CreateMonitor()
 	go func() {
 		ctl.Run(stopCh)
 	}()
+	synced = append(synced, ctl.HasSynced)
 	log.Println("wait until controller and cache synced with api server")
 	if err := wait.PollImmediate(1*time.Second, 10*time.Second, func() (bool, error) {
-		return ctl.HasSynced(), nil
+		for _, hasSynced := range synced {
+			if !hasSynced() {
+				return false, nil
+			}
+		}
+		return true, nil
 	}); err != nil {
 		log.Fatalf("error waiting for a controller to sync with api server: %v", err)
 	} else {
@@ -67,6 +162,28 @@ func main() {
 	go func() {
 		ext.RunPromisesCleaner(opts.promisesInterval, stopCh)
 	}()
+	go func() {
+		ext.RunReconciler(opts.reconcileInterval, stopCh)
+	}()
+	if opts.grpcListen != "" {
+		grpcSrv, err := extender.MakeGRPCServer(ext, opts.security)
+		if err != nil {
+			log.Fatal(err)
+		}
+		lis, err := net.Listen("tcp", opts.grpcListen)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go func() {
+			log.Fatal(grpcSrv.Serve(lis))
+		}()
+	}
 	srv := extender.MakeServer(ext, opts.listen)
+	if err := extender.SecureServer(srv, opts.security); err != nil {
+		log.Fatal(err)
+	}
+	if opts.security.CertFile != "" {
+		log.Fatal(srv.ListenAndServeTLS("", ""))
+	}
 	log.Fatal(srv.ListenAndServe())
 }
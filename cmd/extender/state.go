@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/extender"
+	"github.com/spf13/pflag"
+)
+
+const stateCommand = "state"
+
+// runState prints VFs accounting of a running extender.
+func runState(args []string) {
+	flags := pflag.NewFlagSet(stateCommand, pflag.ExitOnError)
+	server := flags.StringP("server", "s", "http://localhost:8989", "Address of the extender.")
+	output := flags.StringP("output", "o", "table", "Output format, table or json.")
+	caFile := flags.String("certificate-authority", "", "CA bundle to verify certificate of the extender.")
+	certFile := flags.String("client-certificate", "", "Client certificate for the extender.")
+	keyFile := flags.String("client-key", "", "Key of the client certificate.")
+	token := flags.String("token", "", "Bearer token for the extender.")
+	flags.Parse(args)
+
+	tlsConfig := &tls.Config{}
+	if *caFile != "" {
+		data, err := ioutil.ReadFile(*caFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+			log.Fatalf("No certificates found in %s", *caFile)
+		}
+	}
+	if *certFile != "" {
+		cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	req, err := http.NewRequest(http.MethodGet, *server+"/state", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *token != "" {
+		req.Header.Set("Authorization", "Bearer "+*token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatalf("Error requesting state from %s: %v", *server, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		errResp := &extender.ErrorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(errResp); err != nil {
+			log.Fatalf("Extender %s responded with %s", *server, resp.Status)
+		}
+		log.Fatalf("Extender %s responded with %s: %s: %s", *server, resp.Status, errResp.Code, errResp.Message)
+	}
+	state := &extender.ClusterState{}
+	if err := json.NewDecoder(resp.Body).Decode(state); err != nil {
+		log.Fatalf("Error decoding state: %v", err)
+	}
+	switch *output {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(state)
+	case "table":
+		err = extender.WriteStateTable(os.Stdout, state)
+	default:
+		err = fmt.Errorf("unknown output format %s", *output)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
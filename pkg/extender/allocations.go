@@ -0,0 +1,189 @@
+package extender
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const (
+	// kubeletOutOfPrefix is a prefix of pod status reason set by kubelet
+	// when it rejects a pod because of insufficient resource on a node.
+	kubeletOutOfPrefix = "OutOf"
+)
+
+// allocation is a VF request of a pod accounted against a node.
+type allocation struct {
+	node      string
+	namespace string
+	name      string
+	req       VFRequest
+	// pf VFs are taken from, empty if it isn't known
+	pf string
+}
+
+// allocate accounts pod VFs against its node, it is a no-op if pod is already accounted.
+// It returns PF VFs are accounted against: the one recorded on the pod, the one chosen
+// when pod was accounted before, or a PF with enough free VFs according to the node state.
+// Must be called with extender lock held.
+func (ext *Extender) allocate(pod *v1.Pod, req VFRequest) string {
+	current, exists := ext.allocations[pod.UID]
+	if exists && current.node == pod.Spec.NodeName && sameRequest(current.req, req) {
+		return current.pf
+	}
+	if exists {
+		ext.release(pod.UID)
+	}
+	var pf string
+	if recorded, ok := podAllocation(pod); ok && sameRequest(recorded.Request(), req) {
+		pf = recorded.PF
+	} else {
+		pf = ext.choosePF(pod, req)
+	}
+	ext.allocations[pod.UID] = allocation{
+		node: pod.Spec.NodeName, namespace: pod.Namespace, name: pod.Name, req: req, pf: pf}
+	allocated := ext.allocated(pod.Spec.NodeName)
+	for _, resName := range req.Resources() {
+		quantity := allocated[resName]
+		quantity.Add(*resource.NewQuantity(req.Count, resource.DecimalSI))
+		allocated[resName] = quantity
+	}
+	observeAllocated(pod.Spec.NodeName, allocated)
+	ext.events.forget(pod.UID)
+	ext.events.eventf(podReference(pod.Namespace, pod.Name, pod.UID), v1.EventTypeNormal, EventReasonVFsAllocated,
+		"Allocated %d VFs of %v on node %s", req.Count, req.Resources(), pod.Spec.NodeName)
+	return pf
+}
+
+// release removes pod VFs from node accounting, it is a no-op if pod is not accounted.
+// Must be called with extender lock held.
+func (ext *Extender) release(uid types.UID) {
+	current, exists := ext.allocations[uid]
+	if !exists {
+		return
+	}
+	delete(ext.allocations, uid)
+	allocated := ext.allocated(current.node)
+	for _, resName := range current.req.Resources() {
+		quantity := allocated[resName]
+		quantity.Sub(*resource.NewQuantity(current.req.Count, resource.DecimalSI))
+		allocated[resName] = quantity
+	}
+	observeAllocated(current.node, allocated)
+	ext.events.forget(uid)
+	ext.events.eventf(podReference(current.namespace, current.name, uid), v1.EventTypeNormal, EventReasonVFsReleased,
+		"Released %d VFs of %v on node %s", current.req.Count, current.req.Resources(), current.node)
+}
+
+// observeAllocated updates allocated VFs gauge of every pool on a node.
+func observeAllocated(node string, allocated v1.ResourceList) {
+	for resName, quantity := range allocated {
+		nodeAllocatedVFs.WithLabelValues(node, string(resName)).Set(float64(quantity.Value()))
+	}
+}
+
+// podTerminated returns true for pods which resources were released by kubelet.
+func podTerminated(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
+
+// isVFResource returns true if resource is reported by discovery.
+func isVFResource(resName v1.ResourceName) bool {
+	for _, mode := range []DriverMode{DriverModeAny, DriverModeNetdev, DriverModeDPDK} {
+		if mode.Resource() == resName {
+			return true
+		}
+	}
+	return false
+}
+
+// checkKubeletRejected detects pods kubelet refused to admit because of insufficient VFs.
+// Such rejection means that extender and kubelet disagree about VFs available on a node.
+func (ext *Extender) checkKubeletRejected(old, new *v1.Pod) {
+	if old.Status.Reason == new.Status.Reason || new.Status.Phase != v1.PodFailed {
+		return
+	}
+	if !strings.HasPrefix(new.Status.Reason, kubeletOutOfPrefix) {
+		return
+	}
+	resName := v1.ResourceName(strings.TrimPrefix(new.Status.Reason, kubeletOutOfPrefix))
+	if !isVFResource(resName) {
+		return
+	}
+	ext.Lock()
+	defer ext.Unlock()
+	allocated := ext.allocated(new.Spec.NodeName)[resName]
+	log.Printf(
+		"Kubelet rejected pod %s/%s on a node %s: %s. Extender accounting disagrees with kubelet, allocated %s: %v",
+		new.Namespace, new.Name, new.Spec.NodeName, new.Status.Message, resName, &allocated)
+}
+
+// Reconcile rebuilds allocations from pods in the monitor cache the same way kubelet
+// accounts them and corrects extender state if it drifted, e.g. because of missed events.
+// Returns names of the nodes which accounting was corrected.
+func (ext *Extender) Reconcile() []string {
+	ext.Lock()
+	defer ext.Unlock()
+	expected := NewExtender(nil)
+	expected.states = ext.states
+	for _, obj := range ext.pods.List() {
+		pod := obj.(*v1.Pod)
+		if req, selected := ext.allocationRequest(pod); selected && !podTerminated(pod) && pod.Spec.NodeName != "" {
+			expected.allocate(pod, req)
+		}
+	}
+	drifted := []string{}
+	nodes := map[string]bool{}
+	for node := range ext.allocatedVFs {
+		nodes[node] = true
+	}
+	for node := range expected.allocatedVFs {
+		nodes[node] = true
+	}
+	for node := range nodes {
+		current := ext.allocated(node)
+		actual := expected.allocated(node)
+		resources := map[v1.ResourceName]bool{}
+		for resName := range current {
+			resources[resName] = true
+		}
+		for resName := range actual {
+			resources[resName] = true
+		}
+		for resName := range resources {
+			currentQuantity, actualQuantity := current[resName], actual[resName]
+			if currentQuantity.Cmp(actualQuantity) != 0 {
+				log.Printf(
+					"Accounting of %s on a node %s drifted. Extender: %v. Kubelet: %v",
+					resName, node, &currentQuantity, &actualQuantity)
+				drifted = append(drifted, node)
+				break
+			}
+		}
+	}
+	for node := range nodes {
+		observeAllocated(node, expected.allocated(node))
+	}
+	driftCorrectionsTotal.Add(float64(len(drifted)))
+	ext.allocatedVFs = expected.allocatedVFs
+	ext.allocations = expected.allocations
+	return drifted
+}
+
+func (ext *Extender) RunReconciler(interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-ticker.C:
+			if drifted := ext.Reconcile(); len(drifted) != 0 {
+				log.Printf("Accounting corrected on nodes %v", drifted)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
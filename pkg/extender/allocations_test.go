@@ -0,0 +1,75 @@
+package extender
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func makeVFPod(uid, node string, count int64) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{UID: types.UID(uid), Name: uid, Namespace: "default"},
+		Spec: v1.PodSpec{
+			NodeName: node,
+			Containers: []v1.Container{{
+				Name: "vf",
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{TotalVFsResource: *resource.NewQuantity(count, resource.DecimalSI)},
+				},
+			}},
+		},
+	}
+}
+
+func TestAllocateIsIdempotent(t *testing.T) {
+	ext := NewExtender(nil)
+	pod := makeVFPod("1", "node1", 2)
+	req, selected, _ := ext.selector(pod)
+	require.True(t, selected)
+	ext.allocate(pod, req)
+	ext.allocate(pod, req)
+	allocated := ext.allocatedVFs["node1"][TotalVFsResource]
+	require.Equal(t, int64(2), allocated.Value())
+	ext.release(pod.UID)
+	ext.release(pod.UID)
+	allocated = ext.allocatedVFs["node1"][TotalVFsResource]
+	require.True(t, allocated.IsZero())
+}
+
+func TestTerminatedPodReleasesVFs(t *testing.T) {
+	ext := NewExtender(nil)
+	pod := makeVFPod("1", "node1", 1)
+	ext.syncAllocated(pod)
+	terminated := *pod
+	terminated.Status.Phase = v1.PodSucceeded
+	ext.syncAllocatedFromUpdated(pod, &terminated)
+	allocated := ext.allocatedVFs["node1"][TotalVFsResource]
+	require.True(t, allocated.IsZero())
+	ext.syncPurged(&terminated)
+	allocated = ext.allocatedVFs["node1"][TotalVFsResource]
+	require.True(t, allocated.IsZero())
+}
+
+func TestReconcile(t *testing.T) {
+	ext := NewExtender(nil)
+	ext.pods = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	require.NoError(t, ext.pods.Add(makeVFPod("1", "node1", 2)))
+	require.NoError(t, ext.pods.Add(makeVFPod("2", "node2", 1)))
+	ext.syncAllocated(makeVFPod("2", "node2", 1))
+	ext.syncAllocated(makeVFPod("3", "node2", 1))
+
+	drifted := ext.Reconcile()
+	sort.Strings(drifted)
+	require.Equal(t, []string{"node1", "node2"}, drifted)
+	node1 := ext.allocatedVFs["node1"][TotalVFsResource]
+	require.Equal(t, int64(2), node1.Value())
+	node2 := ext.allocatedVFs["node2"][TotalVFsResource]
+	require.Equal(t, int64(1), node2.Value())
+	require.Empty(t, ext.Reconcile())
+}
@@ -0,0 +1,96 @@
+package extender
+
+import (
+	"encoding/json"
+	"log"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+// AllocationAnnotation records VFs the extender accounted against a node of a pod.
+const AllocationAnnotation = nodestate.AllocationAnnotation
+
+// Allocation is a value of AllocationAnnotation, CNI plugins can cross-check it with VFs they configure.
+type Allocation struct {
+	Node string `json:"node"`
+	// Pool is the most specific resource VFs are accounted against, e.g. dpdkvfs
+	Pool v1.ResourceName `json:"pool"`
+	Mode DriverMode      `json:"mode,omitempty"`
+	// PF is a physical function VFs are taken from, empty if extender doesn't know states
+	// of nodes or no single PF had enough VFs when pod was bound
+	PF    string `json:"pf,omitempty"`
+	Count int64  `json:"count"`
+}
+
+func newAllocation(node string, req VFRequest, pf string) Allocation {
+	resources := req.Resources()
+	return Allocation{Node: node, Pool: resources[len(resources)-1], Mode: req.Mode, PF: pf, Count: req.Count}
+}
+
+// Request returns VF request the allocation was made for.
+func (a Allocation) Request() VFRequest {
+	return VFRequest{Mode: a.Mode, Count: a.Count, Pool: a.Pool}
+}
+
+// podAllocation returns allocation recorded on a bound pod, false if pod has none or it is invalid.
+func podAllocation(pod *v1.Pod) (Allocation, bool) {
+	value, exists := pod.Annotations[AllocationAnnotation]
+	if !exists {
+		return Allocation{}, false
+	}
+	var alloc Allocation
+	if err := json.Unmarshal([]byte(value), &alloc); err != nil {
+		log.Printf("invalid %s annotation of pod %s/%s: %v\n", AllocationAnnotation, pod.Namespace, pod.Name, err)
+		return Allocation{}, false
+	}
+	if alloc.Node != pod.Spec.NodeName || alloc.Count < 1 {
+		return Allocation{}, false
+	}
+	return alloc, true
+}
+
+// allocationRequest returns VF request of a bound pod. Allocation recorded on the pod takes
+// precedence over selectors, so accounting survives restarts even if configuration changed.
+// Pods with invalid requests are not accounted, filter doesn't pass them.
+func (ext *Extender) allocationRequest(pod *v1.Pod) (VFRequest, bool) {
+	if alloc, recorded := podAllocation(pod); recorded {
+		return alloc.Request(), true
+	}
+	req, selected, err := ext.selector(pod)
+	if err != nil {
+		log.Printf("Pod %s/%s isn't accounted: %v\n", pod.Namespace, pod.Name, err)
+		return VFRequest{}, false
+	}
+	return req, selected
+}
+
+// recordAllocation writes AllocationAnnotation on a pod unless it already has the same value.
+func (ext *Extender) recordAllocation(pod *v1.Pod, req VFRequest, pf string) {
+	if ext.client == nil {
+		return
+	}
+	alloc := newAllocation(pod.Spec.NodeName, req, pf)
+	if recorded, exists := podAllocation(pod); exists && recorded == alloc {
+		return
+	}
+	value, err := json.Marshal(alloc)
+	if err != nil {
+		log.Printf("error marshalling allocation of pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+		return
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{AllocationAnnotation: string(value)},
+		},
+	})
+	if err != nil {
+		log.Printf("error marshalling allocation patch of pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+		return
+	}
+	if _, err := ext.client.Core().Pods(pod.Namespace).Patch(pod.Name, types.MergePatchType, patch); err != nil {
+		log.Printf("error recording allocation of pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+	}
+}
@@ -0,0 +1,91 @@
+package extender
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordAllocation(t *testing.T) {
+	var patches []core.PatchActionImpl
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("patch", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		patches = append(patches, action.(core.PatchActionImpl))
+		return true, nil, nil
+	})
+	ext := NewExtender(client)
+	recorder := record.NewFakeRecorder(10)
+	ext.SetEventRecorder(recorder)
+	pod := makeVFPod("1", "node1", 2)
+	pod.Annotations = map[string]string{DriverModeAnnotation: string(DriverModeDPDK)}
+	ext.SetSelector(func(*v1.Pod) (VFRequest, bool, error) { return VFRequest{Mode: DriverModeDPDK, Count: 2}, true, nil })
+	ext.syncAllocated(pod)
+	require.Len(t, patches, 1)
+	require.Equal(t, "1", patches[0].GetName())
+	var patch struct {
+		Metadata struct {
+			Annotations map[string]string
+		}
+	}
+	require.NoError(t, json.Unmarshal(patches[0].Patch, &patch))
+	require.JSONEq(t, `{"node": "node1", "pool": "dpdkvfs", "mode": "dpdk", "count": 2}`,
+		patch.Metadata.Annotations[AllocationAnnotation])
+
+	require.Equal(t, []string{"Normal VFsAllocated Allocated 2 VFs of [totalvfs dpdkvfs] on node node1"},
+		recordedEvents(recorder))
+
+	pod.Annotations[AllocationAnnotation] = patch.Metadata.Annotations[AllocationAnnotation]
+	ext.syncAllocated(pod)
+	require.Len(t, patches, 1, "recorded allocation should not be patched again")
+	require.Empty(t, recordedEvents(recorder), "recorded allocation should not be released and allocated again")
+}
+
+func TestRecordAllocationPF(t *testing.T) {
+	var patches []core.PatchActionImpl
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("patch", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		patches = append(patches, action.(core.PatchActionImpl))
+		return true, nil, nil
+	})
+	ext := NewExtender(client)
+	ext.SetNodeStates(fakeNodeStates{"node1": makeNodeState(makeStatePF("ens1f0", 0, netdevVF, netdevVF))})
+	ext.syncAllocated(makeVFPod("1", "node1", 2))
+	require.Len(t, patches, 1)
+	var patch struct {
+		Metadata struct {
+			Annotations map[string]string
+		}
+	}
+	require.NoError(t, json.Unmarshal(patches[0].Patch, &patch))
+	require.JSONEq(t, `{"node": "node1", "pool": "totalvfs", "pf": "ens1f0", "count": 2}`,
+		patch.Metadata.Annotations[AllocationAnnotation])
+}
+
+func TestAllocationRebuiltFromAnnotation(t *testing.T) {
+	ext := NewExtender(nil)
+	ext.SetSelector(func(*v1.Pod) (VFRequest, bool, error) { return VFRequest{}, false, nil })
+	pod := makeVFPod("1", "node1", 3)
+	pod.Annotations = map[string]string{
+		AllocationAnnotation: `{"node": "node1", "pool": "dpdkvfs", "mode": "dpdk", "count": 3}`,
+	}
+	ext.syncAllocated(pod)
+	allocated := ext.allocated("node1")
+	for _, resName := range []string{"totalvfs", "dpdkvfs"} {
+		quantity := allocated[v1.ResourceName(resName)]
+		require.Equal(t, int64(3), quantity.Value(), resName)
+	}
+
+	moved := makeVFPod("2", "node2", 1)
+	moved.Annotations = map[string]string{AllocationAnnotation: `{"node": "node1", "pool": "totalvfs", "count": 1}`}
+	_, recorded := podAllocation(moved)
+	require.False(t, recorded, "allocation on other node should be ignored")
+	moved.Annotations[AllocationAnnotation] = "{"
+	_, recorded = podAllocation(moved)
+	require.False(t, recorded)
+}
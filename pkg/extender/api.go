@@ -1,6 +1,9 @@
 package extender
 
-import "k8s.io/client-go/pkg/api/v1"
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/pkg/api/v1"
+)
 
 // ExtenderArgs represents the arguments needed by the extender to filter/prioritize
 // nodes for a pod.
@@ -28,6 +31,8 @@ type ExtenderFilterResult struct {
 	NodeNames *[]string
 	// Filtered out nodes where the pod can't be scheduled and the failure messages
 	FailedNodes FailedNodesMap
+	// Filtered out nodes where the pod can't be scheduled even if other pods are preempted
+	FailedAndUnresolvableNodes FailedNodesMap
 	// Error message indicating failure
 	Error string
 }
@@ -37,7 +42,91 @@ type HostPriority struct {
 	// Name of the host
 	Host string
 	// Score associated with the host
-	Score int
+	Score int64
 }
 
 type HostPriorityList []HostPriority
+
+// ExtenderBindingArgs represents the arguments to an extender for binding a pod to a node.
+type ExtenderBindingArgs struct {
+	// PodName is the name of the pod being bound
+	PodName string `json:"podName"`
+	// PodNamespace is the namespace of the pod being bound
+	PodNamespace string `json:"podNamespace"`
+	// PodUID is the UID of the pod being bound
+	PodUID types.UID `json:"podUID"`
+	// Node selected by the scheduler
+	Node string `json:"node"`
+}
+
+// ExtenderBindingResult represents the result of binding of a pod to a node from an extender.
+type ExtenderBindingResult struct {
+	// Error message indicating failure
+	Error string `json:"error"`
+}
+
+// ExtenderPreemptionArgs represents the arguments needed by the extender to preempt pods on nodes.
+type ExtenderPreemptionArgs struct {
+	// Pod being scheduled
+	Pod *v1.Pod `json:"pod"`
+	// Victims map generated by scheduler preemption; to be populated
+	// only if ExtenderConfig.NodeCacheCapable == false
+	NodeNameToVictims map[string]*Victims `json:"nodeToVictims,omitempty"`
+	// Victims map with UIDs of pods only; to be populated
+	// only if ExtenderConfig.NodeCacheCapable == true
+	NodeNameToMetaVictims map[string]*MetaVictims `json:"nodeNameToMetaVictims,omitempty"`
+}
+
+// ExtenderPreemptionResult represents the result returned by preemption phase of extender.
+type ExtenderPreemptionResult struct {
+	NodeNameToMetaVictims map[string]*MetaVictims `json:"nodeNameToMetaVictims,omitempty"`
+}
+
+// Victims represents pods to be preempted on a node and a number of PDB violations.
+type Victims struct {
+	Pods             []*v1.Pod `json:"pods"`
+	NumPDBViolations int64     `json:"numPDBViolations"`
+}
+
+// MetaPod represents identifier of a pod.
+type MetaPod struct {
+	UID string `json:"uid"`
+}
+
+// MetaVictims represents victims identified by UIDs only.
+type MetaVictims struct {
+	Pods             []*MetaPod `json:"pods"`
+	NumPDBViolations int64      `json:"numPDBViolations"`
+}
+
+// SimulateArgs describes replicas of a pod which placement has to be simulated.
+type SimulateArgs struct {
+	// Pod is a template of every replica
+	Pod v1.Pod
+	// Replicas is a number of pods to place
+	Replicas int
+	// List of candidate nodes; if empty all nodes are fetched from the API
+	Nodes *v1.NodeList
+}
+
+// ReplicaPlacement is a projected node of a single replica.
+type ReplicaPlacement struct {
+	// Replica index starting from 0
+	Replica int
+	// Node replica would be scheduled on
+	Node string
+	// Score of the node for this replica
+	Score int64
+}
+
+// SimulateResult represents the results of a placement simulation
+type SimulateResult struct {
+	// Placements of replicas that fit
+	Placements []ReplicaPlacement
+	// FirstUnschedulable is an index of the first replica that doesn't fit, nil if all of them fit
+	FirstUnschedulable *int
+	// Filtered out nodes for the first unschedulable replica and the failure messages
+	FailedNodes FailedNodesMap
+	// Error message indicating failure
+	Error string
+}
@@ -0,0 +1,23 @@
+package extender
+
+import (
+	"log"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// Bind binds a pod to a node selected by the scheduler. VFs are allocated by the pod monitor
+// once it sees pod with a node name, same as for pods bound by the scheduler itself.
+func (ext *Extender) Bind(args *ExtenderBindingArgs) (interface{}, error) {
+	log.Printf("Bind called with pod %s/%s and node %s", args.PodNamespace, args.PodName, args.Node)
+	binding := &v1.Binding{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: args.PodNamespace, Name: args.PodName, UID: args.PodUID},
+		Target:     v1.ObjectReference{Kind: "Node", Name: args.Node},
+	}
+	if err := ext.client.Core().Pods(args.PodNamespace).Bind(binding); err != nil {
+		log.Printf("Error binding pod %s/%s to node %s: %v", args.PodNamespace, args.PodName, args.Node, err)
+		return &ExtenderBindingResult{Error: err.Error()}, nil
+	}
+	return &ExtenderBindingResult{}, nil
+}
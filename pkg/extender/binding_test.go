@@ -0,0 +1,39 @@
+package extender
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+	core "k8s.io/client-go/testing"
+)
+
+func TestBind(t *testing.T) {
+	var bindings []*v1.Binding
+	var bindErr error
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		if bindErr != nil {
+			return true, nil, bindErr
+		}
+		binding := action.(core.CreateAction).GetObject().(*v1.Binding)
+		bindings = append(bindings, binding)
+		return true, binding, nil
+	})
+	ext := NewExtender(client)
+	result, err := ext.Bind(&ExtenderBindingArgs{PodName: "pod", PodNamespace: "default", PodUID: "pod-uid", Node: "node-0"})
+	require.NoError(t, err)
+	require.Equal(t, &ExtenderBindingResult{}, result)
+	require.Len(t, bindings, 1)
+	require.Equal(t, "pod", bindings[0].Name)
+	require.Equal(t, "default", bindings[0].Namespace)
+	require.Equal(t, "node-0", bindings[0].Target.Name)
+
+	bindErr = fmt.Errorf("pod is already bound")
+	result, err = ext.Bind(&ExtenderBindingArgs{PodName: "pod", PodNamespace: "default", Node: "node-1"})
+	require.NoError(t, err)
+	require.Equal(t, &ExtenderBindingResult{Error: "pod is already bound"}, result)
+}
@@ -0,0 +1,73 @@
+package extender
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// Config is a configuration file of the extender.
+type Config struct {
+	// Rules decide which pods require VFs. If there are no rules NetworkSelector is used.
+	Rules []SelectorRule `json:"rules,omitempty"`
+	// APIVersion is a revision of the scheduler extender protocol, DefaultAPIVersion if empty.
+	APIVersion APIVersion `json:"apiVersion,omitempty"`
+}
+
+// LoadConfig reads configuration from a YAML or JSON file and validates it.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %v", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// Validate verifies that configuration can be used by the extender.
+func (c *Config) Validate() error {
+	if _, err := ProtocolFor(c.APIVersion); err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if names[rule.Name] {
+			return fmt.Errorf("rule %d: duplicate name %q", i, rule.Name)
+		}
+		names[rule.Name] = true
+		if _, err := rule.compile(); err != nil {
+			return fmt.Errorf("rule %d (%s): %v", i, rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// NeedsNamespaces returns true if any of the rules selects pods by namespace labels.
+func (c *Config) NeedsNamespaces() bool {
+	for _, rule := range c.Rules {
+		if rule.NamespaceSelector != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Pools returns pools named by the rules.
+func (c *Config) Pools() []v1.ResourceName {
+	pools := []v1.ResourceName{}
+	for _, rule := range c.Rules {
+		if rule.Pool != "" {
+			pools = append(pools, rule.Pool)
+		}
+	}
+	return pools
+}
@@ -0,0 +1,42 @@
+package extender
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestLoadConfig(t *testing.T) {
+	f, err := ioutil.TempFile("", "extender-config")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(`
+apiVersion: v1.20
+rules:
+- name: dpdk
+  annotation: networks
+  token: sriov-dpdk
+  mode: dpdk
+  pool: dpdkvfs
+- name: cnf
+  namespaceSelector:
+    matchLabels:
+      sriov: enabled
+`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	config, err := LoadConfig(f.Name())
+	require.NoError(t, err)
+	require.Len(t, config.Rules, 2)
+	require.Equal(t, DriverModeDPDK, config.Rules[0].Mode)
+	require.Equal(t, "enabled", config.Rules[1].NamespaceSelector.MatchLabels["sriov"])
+	require.True(t, config.NeedsNamespaces())
+	require.Equal(t, APIVersion120, config.APIVersion)
+	require.Equal(t, []v1.ResourceName{DPDKVFsResource}, config.Pools())
+
+	require.Error(t, (&Config{APIVersion: "v0.1"}).Validate())
+}
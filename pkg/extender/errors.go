@@ -0,0 +1,58 @@
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// ErrorCode identifies a class of errors returned by the extender.
+type ErrorCode string
+
+const (
+	ErrorCodeMethodNotAllowed ErrorCode = "MethodNotAllowed"
+	ErrorCodeMalformedRequest ErrorCode = "MalformedRequest"
+	ErrorCodeInvalidRequest   ErrorCode = "InvalidRequest"
+	ErrorCodeNotFound         ErrorCode = "NotFound"
+	ErrorCodeRequestTooLarge  ErrorCode = "RequestTooLarge"
+	ErrorCodeUnauthorized     ErrorCode = "Unauthorized"
+	ErrorCodeUnavailable      ErrorCode = "Unavailable"
+	ErrorCodeInternal         ErrorCode = "Internal"
+)
+
+// ErrorResponse is a body of every response of the extender with a non-200 status code.
+type ErrorResponse struct {
+	// Code of the error
+	Code ErrorCode
+	// Message describing the error
+	Message string
+}
+
+// writeError responds with a status code and an ErrorResponse.
+func writeError(w http.ResponseWriter, status int, code ErrorCode, format string, args ...interface{}) {
+	body, err := json.Marshal(&ErrorResponse{Code: code, Message: fmt.Sprintf(format, args...)})
+	if err != nil {
+		log.Printf("error marshalling error response: %v\n", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("error writing response body: %v", err)
+	}
+}
+
+// recoverPanics responds with an internal error instead of dropping connection if a handler panics.
+func recoverPanics(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Printf("panic serving %s: %v\n%s", r.URL.Path, p, debug.Stack())
+				writeError(w, http.StatusInternalServerError, ErrorCodeInternal, "internal error serving %s", r.URL.Path)
+			}
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,145 @@
+package extender
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	EventReasonWaitingForVFs  = "WaitingForPromisedVFs"
+	EventReasonNoVFs          = "NoNodeHasVFs"
+	EventReasonPromiseExpired = "VFPromiseExpired"
+	EventReasonVFsAllocated   = "VFsAllocated"
+	EventReasonVFsReleased    = "VFsReleased"
+	EventReasonNodeOutOfVFs   = "NodeOutOfVFs"
+
+	eventsComponent = "sriov-extender"
+	// eventsDedupWindow suppresses an event if the same one was emitted for an object recently.
+	eventsDedupWindow = time.Minute
+	// eventsQPS and eventsBurst limit rate of events posted to the API server.
+	eventsQPS   = 5
+	eventsBurst = 25
+	// maxRecentEvents bounds memory used to deduplicate events.
+	maxRecentEvents = 4096
+)
+
+// NewEventRecorder creates a recorder which posts events to the API server.
+// Events repeated by the extender are aggregated by the recorder same as events of kubelet.
+func NewEventRecorder(client kubernetes.Interface) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: client.Core().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: eventsComponent})
+}
+
+// SetEventRecorder makes extender explain its decisions with events on pods and nodes.
+func (ext *Extender) SetEventRecorder(recorder record.EventRecorder) {
+	ext.events = newEventRecorder(recorder, flowcontrol.NewTokenBucketRateLimiter(eventsQPS, eventsBurst))
+	ext.promises.OnExpired(ext.events.promiseExpired)
+}
+
+// eventRecorder deduplicates and rate limits events, all methods are no-op on nil recorder.
+type eventRecorder struct {
+	recorder record.EventRecorder
+	limiter  flowcontrol.RateLimiter
+	now      func() time.Time
+
+	sync.Mutex
+	recent map[string]time.Time
+	// promised keeps references of pods waiting for promised VFs, promises know only pod UIDs
+	promised map[types.UID]*v1.ObjectReference
+}
+
+func newEventRecorder(recorder record.EventRecorder, limiter flowcontrol.RateLimiter) *eventRecorder {
+	return &eventRecorder{
+		recorder: recorder,
+		limiter:  limiter,
+		now:      time.Now,
+		recent:   map[string]time.Time{},
+		promised: map[types.UID]*v1.ObjectReference{},
+	}
+}
+
+func podReference(namespace, name string, uid types.UID) *v1.ObjectReference {
+	return &v1.ObjectReference{Kind: "Pod", Namespace: namespace, Name: name, UID: uid}
+}
+
+func nodeReference(name string) *v1.ObjectReference {
+	// kubelet uses node name as UID of node events, kubectl describe relies on it
+	return &v1.ObjectReference{Kind: "Node", Name: name, UID: types.UID(name)}
+}
+
+func (r *eventRecorder) eventf(ref *v1.ObjectReference, eventtype, reason, format string, args ...interface{}) {
+	if r == nil {
+		return
+	}
+	message := fmt.Sprintf(format, args...)
+	key := fmt.Sprintf("%s/%s/%s/%s/%s/%s", ref.Kind, ref.Namespace, ref.Name, ref.UID, reason, message)
+	now := r.now()
+	r.Lock()
+	if emitted, exists := r.recent[key]; exists && now.Sub(emitted) < eventsDedupWindow {
+		r.Unlock()
+		return
+	}
+	if len(r.recent) >= maxRecentEvents {
+		for recentKey, emitted := range r.recent {
+			if now.Sub(emitted) >= eventsDedupWindow {
+				delete(r.recent, recentKey)
+			}
+		}
+	}
+	if !r.limiter.TryAccept() {
+		r.Unlock()
+		log.Printf("event %s for %s %s/%s dropped by rate limiter\n", reason, ref.Kind, ref.Namespace, ref.Name)
+		return
+	}
+	if len(r.recent) < maxRecentEvents {
+		r.recent[key] = now
+	}
+	r.Unlock()
+	r.recorder.Event(ref, eventtype, reason, message)
+}
+
+// promise remembers a pod VFs are promised to, to explain its expiration.
+func (r *eventRecorder) promise(pod *v1.Pod) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.promised[pod.UID] = podReference(pod.Namespace, pod.Name, pod.UID)
+}
+
+// forget drops a pod which promise was kept or purged.
+func (r *eventRecorder) forget(uid types.UID) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	delete(r.promised, uid)
+}
+
+func (r *eventRecorder) promiseExpired(uid types.UID) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	ref, exists := r.promised[uid]
+	delete(r.promised, uid)
+	r.Unlock()
+	if !exists {
+		return
+	}
+	r.eventf(ref, v1.EventTypeWarning, EventReasonPromiseExpired,
+		"VFs promised to the pod expired before it was bound to a node")
+}
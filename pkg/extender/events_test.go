@@ -0,0 +1,112 @@
+package extender
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestAllocationEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	ext := NewExtender(nil)
+	ext.SetEventRecorder(recorder)
+	pod := makeVFPod("1", "node1", 2)
+	req, _, _ := ext.selector(pod)
+	ext.allocate(pod, req)
+	ext.allocate(pod, req)
+	ext.release(pod.UID)
+	require.Equal(t, []string{
+		"Normal VFsAllocated Allocated 2 VFs of [totalvfs] on node node1",
+		"Normal VFsReleased Released 2 VFs of [totalvfs] on node node1",
+	}, recordedEvents(recorder))
+}
+
+func TestFilterEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	ext := NewExtender(nil)
+	ext.SetEventRecorder(recorder)
+	ext.allocate(makeVFPod("bound", "0", 1), VFRequest{Count: 1})
+	args := makeExtenderArgs([]int64{1})
+	args.Pod.Namespace, args.Pod.Name = "default", "pending"
+	for i := 0; i < 2; i++ {
+		result, err := ext.FilterArgs(args)
+		require.NoError(t, err)
+		require.Empty(t, result.(*ExtenderFilterResult).Nodes.Items)
+	}
+	require.Equal(t, []string{
+		"Normal VFsAllocated Allocated 1 VFs of [totalvfs] on node 0",
+		"Warning NodeOutOfVFs All allocatable totalvfs are allocated to pods",
+		"Warning NoNodeHasVFs 0/1 nodes have 1 free VFs of [totalvfs]",
+	}, recordedEvents(recorder), "repeated events should be deduplicated")
+}
+
+func TestPromiseExpiredEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	ext := NewExtender(nil)
+	ext.SetEventRecorder(recorder)
+	args := makeExtenderArgs([]int64{2})
+	args.Pod.Namespace, args.Pod.Name = "default", "pending"
+	_, err := ext.FilterArgs(args)
+	require.NoError(t, err)
+	promises := ext.promises.(*Promises)
+	promises.Lock()
+	stale := promises.promises[args.Pod.UID]
+	stale.made = stale.made.Add(-promiseTimeout)
+	promises.promises[args.Pod.UID] = stale
+	promises.Unlock()
+	ext.events.promiseExpired(types.UID("unknown"))
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go ext.promises.RunPromisesCleaner(5*time.Millisecond, stopCh)
+	events := []string{}
+	Eventually(t, func() error {
+		events = append(events, recordedEvents(recorder)...)
+		if len(events) == 0 {
+			return fmt.Errorf("Expected an event once the promise expires")
+		}
+		return nil
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, []string{
+		"Warning VFPromiseExpired VFs promised to the pod expired before it was bound to a node",
+	}, events)
+	require.Empty(t, ext.promises.List())
+}
+
+func TestEventsRateLimitedAndDeduplicated(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	events := newEventRecorder(recorder, flowcontrol.NewFakeAlwaysRateLimiter())
+	now := time.Now()
+	events.now = func() time.Time { return now }
+	ref := podReference("default", "pod", "1")
+	events.eventf(ref, "Normal", "Reason", "message")
+	events.eventf(ref, "Normal", "Reason", "message")
+	events.eventf(ref, "Normal", "Reason", "other message")
+	now = now.Add(eventsDedupWindow)
+	events.eventf(ref, "Normal", "Reason", "message")
+	require.Len(t, recordedEvents(recorder), 3)
+
+	events.limiter = flowcontrol.NewFakeNeverRateLimiter()
+	events.eventf(ref, "Normal", "Reason", "throttled")
+	require.Empty(t, recordedEvents(recorder))
+
+	var disabled *eventRecorder
+	disabled.eventf(ref, "Normal", "Reason", "message")
+}
@@ -0,0 +1,205 @@
+package extender
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const (
+	// RuleNoPool rejects nodes that don't report a pool requested by a pod.
+	RuleNoPool = "NoPool"
+	// RuleInsufficientVFs rejects nodes without enough free VFs in a pool.
+	RuleInsufficientVFs = "InsufficientVFs"
+	// RuleLinkDown rejects nodes where links of all PFs are down.
+	RuleLinkDown = "LinkDown"
+	// RuleTopology rejects nodes without enough usable VFs on PFs requested with PFAnnotation or NUMANodeAnnotation.
+	RuleTopology = "Topology"
+)
+
+// PodVFs is a number of VFs allocated or promised to a pod.
+type PodVFs struct {
+	// Pod is namespace/name of the pod, empty for promises which are tracked by UID only
+	Pod   string
+	UID   types.UID
+	Count int64
+}
+
+// PoolExplanation is accounting of a pool requested by a pod on a node.
+type PoolExplanation struct {
+	Pool v1.ResourceName
+	// Capacity is reported by discovery
+	Capacity int64
+	// Reserved is a part of capacity that is not allocatable
+	Reserved int64
+	// Allocated to pods bound to the node
+	Allocated int64
+	// Promised to pods that passed filter but not yet bound, promises apply to every node
+	Promised int64
+	// Free VFs that can be used by the pod
+	Free int64
+	// Requested by the pod
+	Requested   int64
+	Allocations []PodVFs
+	Promises    []PodVFs
+}
+
+// NodeExplanation tells if a pod fits a node and which rule rejected it.
+type NodeExplanation struct {
+	Node  string
+	Fits  bool
+	Pools []PoolExplanation
+	// Rule that rejected the node, empty if pod fits
+	Rule string
+	// Reason reported to the scheduler
+	Reason string
+}
+
+// QuotaUsage is a resource quota limiting VFs of a namespace.
+type QuotaUsage struct {
+	Name     string
+	Resource v1.ResourceName
+	Hard     int64
+	Used     int64
+}
+
+// Explanation is a breakdown of VFs accounting of every node for a pod.
+type Explanation struct {
+	Pod string
+	// Request of the pod, nil if pod doesn't require VFs
+	Request *VFRequest
+	Quotas  []QuotaUsage
+	Nodes   []NodeExplanation
+}
+
+// Explain re-evaluates a pod against every node without making promises.
+func (ext *Extender) Explain(namespace, name string) (*Explanation, error) {
+	pod, err := ext.client.Core().Pods(namespace).Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	explanation := &Explanation{Pod: namespace + "/" + name, Quotas: []QuotaUsage{}, Nodes: []NodeExplanation{}}
+	req, selected, err := ext.selector(pod)
+	if err != nil {
+		return nil, err
+	}
+	if !selected {
+		return explanation, nil
+	}
+	explanation.Request = &req
+	topo, err := podTopology(pod)
+	if err != nil {
+		return nil, err
+	}
+	if explanation.Quotas, err = ext.quotas(namespace, req); err != nil {
+		return nil, err
+	}
+	nodes, err := ext.client.Core().Nodes().List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	explanation.Nodes = ext.explain(req, topo, nodes.Items)
+	return explanation, nil
+}
+
+// explain checks every node same as FilterArgs does, promise of the pod itself is accounted if it was made.
+func (ext *Extender) explain(req VFRequest, topo topology, nodes []v1.Node) []NodeExplanation {
+	ext.Lock()
+	defer ext.Unlock()
+	promised := ext.promises.PromisesCount()
+	promises := []PodVFs{}
+	for _, promise := range ext.promises.List() {
+		promises = append(promises, PodVFs{UID: promise.UID, Count: promise.Count})
+	}
+	sort.Slice(promises, func(i, j int) bool {
+		return promises[i].UID < promises[j].UID
+	})
+	allocations := map[string][]allocationWithUID{}
+	for uid, alloc := range ext.allocations {
+		allocations[alloc.node] = append(allocations[alloc.node], allocationWithUID{uid, alloc})
+	}
+	explained := make([]NodeExplanation, 0, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		allocated := ext.allocated(node.Name)
+		nodeExplanation := NodeExplanation{Node: node.Name, Pools: []PoolExplanation{}}
+		rule, reason, _ := ext.fitNode(node, req, topo, allocated, promised, 0)
+		nodeExplanation.Rule, nodeExplanation.Fits = rule, rule == ""
+		nodeExplanation.Reason = reason
+		for _, pool := range req.Resources() {
+			capacity := node.Status.Capacity[pool]
+			allocatable := node.Status.Allocatable[pool]
+			used := allocated[pool]
+			poolExplanation := PoolExplanation{
+				Pool:        pool,
+				Capacity:    capacity.Value(),
+				Reserved:    capacity.Value() - allocatable.Value(),
+				Allocated:   used.Value(),
+				Promised:    promised.Value(),
+				Free:        allocatable.Value() - used.Value() - promised.Value(),
+				Requested:   req.Count,
+				Allocations: []PodVFs{},
+				Promises:    promises,
+			}
+			for _, alloc := range allocations[node.Name] {
+				for _, resName := range alloc.req.Resources() {
+					if resName == pool {
+						poolExplanation.Allocations = append(poolExplanation.Allocations, PodVFs{
+							Pod: alloc.namespace + "/" + alloc.name, UID: alloc.uid, Count: alloc.req.Count})
+					}
+				}
+			}
+			sort.Slice(poolExplanation.Allocations, func(i, j int) bool {
+				return poolExplanation.Allocations[i].Pod < poolExplanation.Allocations[j].Pod
+			})
+			nodeExplanation.Pools = append(nodeExplanation.Pools, poolExplanation)
+		}
+		explained = append(explained, nodeExplanation)
+	}
+	sort.Slice(explained, func(i, j int) bool {
+		return explained[i].Node < explained[j].Node
+	})
+	return explained
+}
+
+type allocationWithUID struct {
+	uid types.UID
+	allocation
+}
+
+// quotas returns resource quotas of a namespace limiting resources requested by a pod.
+func (ext *Extender) quotas(namespace string, req VFRequest) ([]QuotaUsage, error) {
+	quotas, err := ext.client.Core().ResourceQuotas(namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing resource quotas of namespace %s: %v", namespace, err)
+	}
+	requested := map[v1.ResourceName]bool{}
+	for _, resName := range req.Resources() {
+		requested[resName] = true
+	}
+	usages := []QuotaUsage{}
+	for _, quota := range quotas.Items {
+		for resName, hard := range quota.Status.Hard {
+			trimmed := strings.TrimPrefix(strings.TrimPrefix(string(resName), "requests."), "limits.")
+			if !requested[v1.ResourceName(trimmed)] {
+				continue
+			}
+			used := quota.Status.Used[resName]
+			usages = append(usages, QuotaUsage{
+				Name: quota.Name, Resource: resName, Hard: hard.Value(), Used: used.Value()})
+		}
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Name == usages[j].Name {
+			return usages[i].Resource < usages[j].Resource
+		}
+		return usages[i].Name < usages[j].Name
+	})
+	log.Printf("Found %d quotas of VFs in namespace %s", len(usages), namespace)
+	return usages, nil
+}
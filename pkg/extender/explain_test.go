@@ -0,0 +1,98 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestExplain(t *testing.T) {
+	nodes := []v1.Node{makeNode(0, 4), makeNode(1, 2), {ObjectMeta: meta_v1.ObjectMeta{Name: "2"}}}
+	nodes[0].Status.Capacity = v1.ResourceList{TotalVFsResource: *resource.NewQuantity(5, resource.DecimalSI)}
+	pending := makeVFPod("pending", "", 2)
+	quota := &v1.ResourceQuota{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "vfs", Namespace: "default"},
+		Status: v1.ResourceQuotaStatus{
+			Hard: v1.ResourceList{
+				"requests." + TotalVFsResource: *resource.NewQuantity(8, resource.DecimalSI),
+				v1.ResourcePods:                *resource.NewQuantity(10, resource.DecimalSI),
+			},
+			Used: v1.ResourceList{"requests." + TotalVFsResource: *resource.NewQuantity(3, resource.DecimalSI)},
+		},
+	}
+	client := fake.NewSimpleClientset(pending, quota, &nodes[0], &nodes[1], &nodes[2])
+	ext := NewExtender(client)
+	bound := makeVFPod("bound", "0", 1)
+	req, _, _ := ext.selector(bound)
+	ext.allocate(bound, req)
+	ext.promises.MakePromise(types.UID("other"), 1)
+
+	explanation, err := ext.Explain("default", "pending")
+	require.NoError(t, err)
+	require.Equal(t, "default/pending", explanation.Pod)
+	require.Equal(t, int64(2), explanation.Request.Count)
+	require.Equal(t, []QuotaUsage{{Name: "vfs", Resource: "requests." + TotalVFsResource, Hard: 8, Used: 3}},
+		explanation.Quotas)
+	require.Len(t, explanation.Nodes, 3)
+
+	fits := explanation.Nodes[0]
+	require.True(t, fits.Fits)
+	require.Empty(t, fits.Rule)
+	require.Equal(t, []PoolExplanation{{
+		Pool: TotalVFsResource, Capacity: 5, Reserved: 1, Allocated: 1, Promised: 1, Free: 2, Requested: 2,
+		Allocations: []PodVFs{{Pod: "default/bound", UID: "bound", Count: 1}},
+		Promises:    []PodVFs{{UID: "other", Count: 1}},
+	}}, fits.Pools)
+
+	insufficient := explanation.Nodes[1]
+	require.False(t, insufficient.Fits)
+	require.Equal(t, RuleInsufficientVFs, insufficient.Rule)
+	require.Contains(t, insufficient.Reason, "Not sufficient number of totalvfs")
+	require.Equal(t, int64(1), insufficient.Pools[0].Free)
+	require.Empty(t, insufficient.Pools[0].Allocations)
+
+	noPool := explanation.Nodes[2]
+	require.False(t, noPool.Fits)
+	require.Equal(t, RuleNoPool, noPool.Rule)
+
+	require.Equal(t, int64(1), ext.promises.List()[0].Count, "explain must not make promises")
+}
+
+func TestExplainHandler(t *testing.T) {
+	ext := NewExtender(fake.NewSimpleClientset(makeVFPod("pending", "", 1)))
+	srv := httptest.NewServer(MakeExplainHandler(ext.Explain))
+	defer srv.Close()
+
+	for i, tc := range []struct {
+		query  string
+		status int
+		code   ErrorCode
+	}{
+		{"?pod=default/pending", http.StatusOK, ""},
+		{"?pod=default/missing", http.StatusNotFound, ErrorCodeNotFound},
+		{"?pod=pending", http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{"", http.StatusBadRequest, ErrorCodeInvalidRequest},
+	} {
+		resp, err := http.Get(srv.URL + tc.query)
+		require.NoError(t, err)
+		require.Equal(t, tc.status, resp.StatusCode, "case %d", i)
+		if tc.code == "" {
+			var explanation Explanation
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&explanation))
+			require.Equal(t, "default/pending", explanation.Pod, "case %d", i)
+		} else {
+			var errResp ErrorResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+			require.Equal(t, tc.code, errResp.Code, "case %d", i)
+		}
+		resp.Body.Close()
+	}
+}
@@ -8,48 +8,74 @@ import (
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 const (
-	TotalVFsResource v1.ResourceName = "totalvfs"
+	TotalVFsResource  v1.ResourceName = "totalvfs"
+	NetdevVFsResource v1.ResourceName = "netdevvfs"
+	DPDKVFsResource   v1.ResourceName = "dpdkvfs"
 )
 
 var (
+	// errNodeNamesUnsupported is returned if scheduler sends node names instead of nodes.
+	errNodeNamesUnsupported = fmt.Errorf("nodes are required, extender has to be configured with nodeCacheCapable false")
+
 	singleItem = resource.NewQuantity(1, resource.DecimalSI)
 	zero       = resource.NewQuantity(0, resource.DecimalSI)
 )
 
-func NewExtender(client *kubernetes.Clientset) *Extender {
+func NewExtender(client kubernetes.Interface) *Extender {
 	return &Extender{
 		client:       client,
-		allocatedVFs: make(map[string]*resource.Quantity),
+		allocatedVFs: make(map[string]v1.ResourceList),
+		allocations:  make(map[types.UID]allocation),
 		promises:     NewPromises(),
-		selector:     NetworkSelector,
+		selector:     FallbackSelector(ResourceSelector, NetworkSelector),
+		health:       &health{readyTimeout: defaultReadyTimeout},
+		protocol:     protocols[DefaultAPIVersion],
 	}
 }
 
 type Extender struct {
-	client *kubernetes.Clientset
+	client kubernetes.Interface
+	pods   cache.Store
 
 	sync.Mutex
-	allocatedVFs map[string]*resource.Quantity
+	allocatedVFs map[string]v1.ResourceList
+	allocations  map[types.UID]allocation
 	promises     PromisesInterface
 
 	selector Selector
+	health   *health
+	protocol *Protocol
+	events   *eventRecorder
+	states   NodeStates
+	pools    []PoolLister
 }
 
 func (ext *Extender) FilterArgs(args *ExtenderArgs) (interface{}, error) {
 	log.Printf("Filter called with pod %s/%s and args %v", args.Pod.Namespace, args.Pod.Name, args)
-	if !ext.selector(&args.Pod) {
-		return nil, nil
+	req, selected, err := ext.selector(&args.Pod)
+	if err != nil || !selected {
+		return nil, err
+	}
+	if args.Nodes == nil {
+		return nil, errNodeNamesUnsupported
+	}
+	topo, err := podTopology(&args.Pod)
+	if err != nil {
+		return nil, err
 	}
 	ext.Lock()
 	defer ext.Unlock()
 	result := &ExtenderFilterResult{
-		Nodes:       &v1.NodeList{Items: make([]v1.Node, 0, 1)},
-		FailedNodes: make(map[string]string),
+		Nodes:                      &v1.NodeList{Items: make([]v1.Node, 0, 1)},
+		FailedNodes:                make(map[string]string),
+		FailedAndUnresolvableNodes: make(map[string]string),
 	}
 	for {
 		var waitChan chan struct{}
@@ -60,73 +86,194 @@ func (ext *Extender) FilterArgs(args *ExtenderArgs) (interface{}, error) {
 		}
 		for _, node := range args.Nodes.Items {
 			log.Printf("Checking node %s", node.Name)
-			if _, exists := ext.allocatedVFs[node.Name]; !exists {
-				ext.allocatedVFs[node.Name] = resource.NewQuantity(0, resource.DecimalSI)
+			allocated := ext.allocated(node.Name)
+			observeNode(&node, req, allocated, promised)
+			if resName, exhausted := nodeExhausted(&node, req, allocated); exhausted {
+				ext.events.eventf(nodeReference(node.Name), v1.EventTypeWarning, EventReasonNodeOutOfVFs,
+					"All allocatable %s are allocated to pods", resName)
 			}
-			allocated := ext.allocatedVFs[node.Name]
-			if res, exists := node.Status.Allocatable[TotalVFsResource]; !exists {
-				log.Printf("No allocatable vfs on a node %s \n", node.Name)
-				continue
-			} else {
-				log.Printf("Node %s has a total of %v allocatable vfs.", node.Name, &res)
-				res.Sub(*allocated)
-				res.Sub(*promised)
-				if res.Cmp(*zero) == 1 {
-					log.Printf(
-						"Node %s has an available VF and it will be promised to a pod %s/%s.",
-						node.Name, args.Pod.Namespace, args.Pod.Name)
-					result.Nodes.Items = append(result.Nodes.Items, node)
+			if rule, reason, unresolvable := ext.fitNode(&node, req, topo, allocated, promised, 0); rule != "" {
+				filterNodesTotal.WithLabelValues("failed").Inc()
+				if unresolvable {
+					// preempting pods doesn't help such a node
+					result.FailedAndUnresolvableNodes[node.Name] = reason
 				} else {
-					log.Printf("Node %s doesnt have sufficient number of VFs", node.Name)
-					result.FailedNodes[node.Name] = fmt.Sprintf(
-						"Not sufficient number of VFs. Allocated: %v. Promised: %v. Total: %v",
-						allocated, promised, res,
-					)
+					result.FailedNodes[node.Name] = reason
 				}
+				continue
 			}
+			filterNodesTotal.WithLabelValues("passed").Inc()
+			log.Printf(
+				"Node %s has an available VF and it will be promised to a pod %s/%s.",
+				node.Name, args.Pod.Namespace, args.Pod.Name)
+			result.Nodes.Items = append(result.Nodes.Items, node)
 		}
 		if len(result.Nodes.Items) == 0 {
 			result.Error = "No nodes have available VFs."
 		} else {
-			ext.promises.MakePromise(args.Pod.UID)
+			ext.promises.MakePromise(args.Pod.UID, req.Count)
+			ext.events.promise(&args.Pod)
 		}
+		podRef := podReference(args.Pod.Namespace, args.Pod.Name, args.Pod.UID)
 		if len(result.Error) != 0 && promised.Cmp(*zero) == 1 {
 			log.Println("Some VFs are promised to other pods. We will wait until one will be released.")
-			if err := WaitFor(waitChan, defaultPromisesCleanerInterval); err != nil {
+			ext.events.eventf(podRef, v1.EventTypeNormal, EventReasonWaitingForVFs,
+				"No node has %d free VFs of %v, waiting for %v VFs promised to other pods",
+				req.Count, req.Resources(), promised)
+			start := time.Now()
+			err := WaitFor(waitChan, defaultPromisesCleanerInterval)
+			promiseWaitSeconds.Observe(time.Since(start).Seconds())
+			if err != nil {
+				filterErrorsTotal.Inc()
+				ext.noVFsEvent(podRef, req, len(args.Nodes.Items))
 				return result, nil
 			}
 			continue
 		}
+		if len(result.Error) != 0 {
+			filterErrorsTotal.Inc()
+			ext.noVFsEvent(podRef, req, len(args.Nodes.Items))
+		}
 		return result, nil
 	}
 }
 
 func (ext *Extender) Prioritize(args *ExtenderArgs) (interface{}, error) {
 	log.Printf("Prioritize called with pod %s/%s and args %v", args.Pod.Namespace, args.Pod.Name, args)
-	if !ext.selector(&args.Pod) {
-		return nil, nil
+	req, selected, err := ext.selector(&args.Pod)
+	if err != nil || !selected {
+		return nil, err
+	}
+	if args.Nodes == nil {
+		return nil, errNodeNamesUnsupported
 	}
 	ext.Lock()
 	defer ext.Unlock()
 	priorityList := HostPriorityList{}
 	promised := ext.promises.PromisesCount()
 	for _, node := range args.Nodes.Items {
-		if _, exists := ext.allocatedVFs[node.Name]; !exists {
-			ext.allocatedVFs[node.Name] = resource.NewQuantity(0, resource.DecimalSI)
+		allocated := ext.allocated(node.Name)
+		observeNode(&node, req, allocated, promised)
+		score, err := scoreNode(&node, req, allocated, promised)
+		if err != nil {
+			return priorityList, err
+		}
+		priorityList = append(priorityList, HostPriority{Host: node.Name, Score: score})
+	}
+	return &priorityList, nil
+}
+
+// fitNode checks if a request fits a node the same way for filter, explain and simulation.
+// It returns a rule that rejected the node, empty if request fits, a failure reason and
+// whether preempting pods can't make the node fit, e.g. because links of all PFs are down
+// or it doesn't report VFs at all. Simulated VFs are placed on the node but not allocated on
+// any PF, topology check doesn't count them as usable. Must be called with extender lock held.
+func (ext *Extender) fitNode(node *v1.Node, req VFRequest, topo topology, allocated v1.ResourceList, promised *resource.Quantity, simulated int64) (string, string, bool) {
+	if reason, down := allLinksDown(node); down {
+		return RuleLinkDown, reason, true
+	}
+	hasVFs, reason := checkNode(node, req, allocated, promised)
+	reason = explainLinks(node, reason)
+	switch {
+	case !hasVFs:
+		return RuleNoPool, reason, true
+	case len(reason) != 0:
+		return RuleInsufficientVFs, reason, false
+	}
+	if hasVFs, reason = ext.checkTopology(node.Name, req, topo, promised.Value()+simulated); len(reason) != 0 {
+		return RuleTopology, reason, !hasVFs
+	}
+	return "", "", false
+}
+
+// checkNode verifies that node has enough VFs for a request. It returns false if node
+// doesn't report VFs at all, and a failure reason if node can't fit the request.
+func checkNode(node *v1.Node, req VFRequest, allocated v1.ResourceList, promised *resource.Quantity) (bool, string) {
+	requested := resource.NewQuantity(req.Count, resource.DecimalSI)
+	for _, resName := range req.Resources() {
+		res, exists := node.Status.Allocatable[resName]
+		if !exists {
+			log.Printf("No allocatable %s on a node %s \n", resName, node.Name)
+			return false, fmt.Sprintf("No allocatable %s", resName)
+		}
+		log.Printf("Node %s has a total of %v allocatable %s.", node.Name, &res, resName)
+		total := res
+		res.Sub(allocated[resName])
+		res.Sub(*promised)
+		if res.Cmp(*requested) < 0 {
+			log.Printf("Node %s doesnt have %v of %s", node.Name, requested, resName)
+			used := allocated[resName]
+			return true, fmt.Sprintf(
+				"Not sufficient number of %s. Allocated: %v. Promised: %v. Total: %v",
+				resName, &used, promised, &total,
+			)
+		}
+	}
+	return true, ""
+}
+
+// nodeExhausted returns a pool of a request which VFs are all allocated on a node.
+func nodeExhausted(node *v1.Node, req VFRequest, allocated v1.ResourceList) (v1.ResourceName, bool) {
+	for _, resName := range req.Resources() {
+		res, exists := node.Status.Allocatable[resName]
+		if !exists || res.IsZero() {
+			continue
+		}
+		if res.Cmp(allocated[resName]) <= 0 {
+			return resName, true
 		}
-		res := node.Status.Allocatable[TotalVFsResource]
-		res.Sub(*ext.allocatedVFs[node.Name])
+	}
+	return "", false
+}
+
+func (ext *Extender) noVFsEvent(podRef *v1.ObjectReference, req VFRequest, nodes int) {
+	ext.events.eventf(podRef, v1.EventTypeWarning, EventReasonNoVFs,
+		"0/%d nodes have %d free VFs of %v", nodes, req.Count, req.Resources())
+}
+
+// scoreNode returns the smallest number of VFs left on a node among resources of a request.
+func scoreNode(node *v1.Node, req VFRequest, allocated v1.ResourceList, promised *resource.Quantity) (int64, error) {
+	var score int64
+	for i, resName := range req.Resources() {
+		res := node.Status.Allocatable[resName]
+		res.Sub(allocated[resName])
 		res.Sub(*promised)
-		score, converted := res.AsInt64()
+		available, converted := res.AsInt64()
 		if !converted {
-			return priorityList, fmt.Errorf("conversion is not possible for %v", &res)
+			return 0, fmt.Errorf("conversion is not possible for %v", &res)
+		}
+		if i == 0 || available < score {
+			score = available
 		}
-		priorityList = append(priorityList, HostPriority{Host: node.Name, Score: int(score)})
 	}
-	return &priorityList, nil
+	return score, nil
+}
+
+// SetSelector replaces selector which decides if pod requires VFs.
+func (ext *Extender) SetSelector(selector Selector) {
+	ext.selector = selector
+}
+
+// SetAPIVersion selects revision of the scheduler extender protocol served by MakeServer.
+func (ext *Extender) SetAPIVersion(version APIVersion) error {
+	protocol, err := ProtocolFor(version)
+	if err != nil {
+		return err
+	}
+	ext.protocol = protocol
+	return nil
+}
+
+// allocated returns VFs allocated on a node, must be called with extender lock held.
+func (ext *Extender) allocated(nodeName string) v1.ResourceList {
+	if _, exists := ext.allocatedVFs[nodeName]; !exists {
+		ext.allocatedVFs[nodeName] = v1.ResourceList{}
+	}
+	return ext.allocatedVFs[nodeName]
 }
 
 func (ext *Extender) RunPromisesCleaner(interval time.Duration, stopCh <-chan struct{}) {
+	ext.health.setCleanerInterval(interval)
 	ext.promises.RunPromisesCleaner(interval, stopCh)
 }
 
@@ -42,7 +42,7 @@ func TestFilter(t *testing.T) {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			ext := NewExtender(nil)
 			for j := 0; j < tc.alreadyPromised; j++ {
-				ext.promises.MakePromise(types.UID(fmt.Sprintf("00%d", j)))
+				ext.promises.MakePromise(types.UID(fmt.Sprintf("00%d", j)), 1)
 			}
 			resultInterface, err := ext.FilterArgs(makeExtenderArgs(tc.nodesResources))
 			if err != nil {
@@ -61,6 +61,39 @@ func TestFilter(t *testing.T) {
 	}
 }
 
+func TestFilterDriverMode(t *testing.T) {
+	ext := NewExtender(nil)
+	args := makeExtenderArgs([]int64{2, 2, 2})
+	args.Pod.Annotations[DriverModeAnnotation] = string(DriverModeDPDK)
+	args.Nodes.Items[0].Status.Allocatable[DPDKVFsResource] = *resource.NewQuantity(2, resource.DecimalSI)
+	args.Nodes.Items[1].Status.Allocatable[NetdevVFsResource] = *resource.NewQuantity(2, resource.DecimalSI)
+	args.Nodes.Items[2].Status.Allocatable[DPDKVFsResource] = *resource.NewQuantity(1, resource.DecimalSI)
+	ext.allocated("2")[DPDKVFsResource] = *resource.NewQuantity(1, resource.DecimalSI)
+	resultInterface, err := ext.FilterArgs(args)
+	require.NoError(t, err)
+	result := resultInterface.(*ExtenderFilterResult)
+	require.Empty(t, result.Error)
+	require.Len(t, result.Nodes.Items, 1)
+	require.Equal(t, "0", result.Nodes.Items[0].Name)
+	require.Contains(t, result.FailedNodes, "2")
+}
+
+func TestFilterRequestedCount(t *testing.T) {
+	ext := NewExtender(nil)
+	args := makeExtenderArgs([]int64{1, 3})
+	args.Pod.Spec.Containers = []v1.Container{{Resources: v1.ResourceRequirements{
+		Limits: v1.ResourceList{TotalVFsResource: *resource.NewQuantity(2, resource.DecimalSI)},
+	}}}
+	resultInterface, err := ext.FilterArgs(args)
+	require.NoError(t, err)
+	result := resultInterface.(*ExtenderFilterResult)
+	require.Len(t, result.Nodes.Items, 1)
+	require.Equal(t, "1", result.Nodes.Items[0].Name)
+	require.Contains(t, result.FailedNodes, "0")
+	promised := ext.promises.PromisesCount()
+	require.Equal(t, int64(2), promised.Value())
+}
+
 func TestPrioritize(t *testing.T) {
 	testCases := []struct {
 		resources     []int64
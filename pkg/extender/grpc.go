@@ -0,0 +1,288 @@
+package extender
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"log"
+	"runtime/debug"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/extenderpb"
+)
+
+// MakeGRPCServer creates gRPC server of the extender secured same as HTTP server.
+func MakeGRPCServer(ext *Extender, opts SecurityOptions) (*grpc.Server, error) {
+	tlsConfig, token, err := loadSecurity(opts)
+	if err != nil {
+		return nil, err
+	}
+	var serverOpts []grpc.ServerOption
+	if tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	interceptor := recoverGRPC
+	if opts.ClientCAFile != "" || token != nil {
+		authenticate := authenticateGRPC(opts.ClientCAFile != "", token)
+		// grpc accepts a single unary interceptor, so authentication runs inside the recovery
+		interceptor = func(
+			ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+		) (interface{}, error) {
+			return recoverGRPC(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return authenticate(ctx, req, info, handler)
+			})
+		}
+	}
+	serverOpts = append(serverOpts, grpc.UnaryInterceptor(interceptor))
+	srv := grpc.NewServer(serverOpts...)
+	extenderpb.RegisterExtenderServer(srv, &grpcExtender{ext: ext})
+	return srv, nil
+}
+
+// recoverGRPC turns a panic of a call into Internal error instead of crashing the extender.
+func recoverGRPC(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("panic serving %s: %v\n%s", info.FullMethod, p, debug.Stack())
+			resp, err = nil, status.Errorf(codes.Internal, "internal error serving %s", info.FullMethod)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// authenticateGRPC rejects calls without verified client certificate or valid bearer token.
+func authenticateGRPC(requireCert bool, token []byte) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		var state *tls.ConnectionState
+		if p, ok := peer.FromContext(ctx); ok {
+			if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+				state = &tlsInfo.State
+			}
+		}
+		var authorization string
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["authorization"]) != 0 {
+			authorization = md["authorization"][0]
+		}
+		if err := checkCredentials(state, authorization, requireCert, token); err != nil {
+			log.Printf("call of %s rejected: %v\n", info.FullMethod, err)
+			return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// maxPromiseCount bounds VFs a single MakePromise call can reserve.
+const maxPromiseCount = 256
+
+// grpcExtender implements extenderpb.ExtenderServer on top of the extender.
+type grpcExtender struct {
+	ext *Extender
+}
+
+// client returns kubernetes client of the extender, calls that need the API fail without it.
+func (s *grpcExtender) client() (kubernetes.Interface, error) {
+	if s.ext.client == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "extender has no kubernetes client")
+	}
+	return s.ext.client, nil
+}
+
+func (s *grpcExtender) Filter(ctx context.Context, req *extenderpb.ExtenderArgs) (*extenderpb.FilterResult, error) {
+	args, err := s.args(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.ext.FilterArgs(args)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	filtered, ok := result.(*ExtenderFilterResult)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "pod doesn't require VFs")
+	}
+	resp := &extenderpb.FilterResult{
+		FailedNodes:                filtered.FailedNodes,
+		FailedAndUnresolvableNodes: filtered.FailedAndUnresolvableNodes,
+		Error:                      filtered.Error,
+	}
+	for _, node := range filtered.Nodes.Items {
+		resp.NodeNames = append(resp.NodeNames, node.Name)
+	}
+	return resp, nil
+}
+
+func (s *grpcExtender) Prioritize(ctx context.Context, req *extenderpb.ExtenderArgs) (*extenderpb.PrioritizeResult, error) {
+	args, err := s.args(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.ext.Prioritize(args)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	priorities, ok := result.(*HostPriorityList)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "pod doesn't require VFs")
+	}
+	resp := &extenderpb.PrioritizeResult{}
+	for _, priority := range *priorities {
+		resp.Priorities = append(resp.Priorities, &extenderpb.HostPriority{Host: priority.Host, Score: priority.Score})
+	}
+	return resp, nil
+}
+
+// args decodes pod and nodes of a request, nodes are fetched from the API if request has none.
+func (s *grpcExtender) args(req *extenderpb.ExtenderArgs) (*ExtenderArgs, error) {
+	args := &ExtenderArgs{Nodes: &v1.NodeList{}}
+	if err := json.Unmarshal(req.Pod, &args.Pod); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error unmarshalling pod: %v", err)
+	}
+	if args.Pod.UID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "pod uid is required")
+	}
+	for _, data := range req.Nodes {
+		var node v1.Node
+		if err := json.Unmarshal(data, &node); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "error unmarshalling node: %v", err)
+		}
+		args.Nodes.Items = append(args.Nodes.Items, node)
+	}
+	if len(args.Nodes.Items) != 0 {
+		return args, nil
+	}
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := client.Core().Nodes().List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "error listing nodes: %v", err)
+	}
+	names := map[string]bool{}
+	for _, name := range req.NodeNames {
+		names[name] = true
+	}
+	for _, node := range nodes.Items {
+		if len(names) == 0 || names[node.Name] {
+			args.Nodes.Items = append(args.Nodes.Items, node)
+		}
+	}
+	return args, nil
+}
+
+func (s *grpcExtender) GetState(ctx context.Context, req *extenderpb.GetStateRequest) (*extenderpb.ClusterState, error) {
+	if _, err := s.client(); err != nil {
+		return nil, err
+	}
+	state, err := s.ext.State()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "%v", err)
+	}
+	resp := &extenderpb.ClusterState{Pools: poolStatesToProto(state.Pools)}
+	for _, node := range state.Nodes {
+		resp.Nodes = append(resp.Nodes, &extenderpb.NodeState{Node: node.Node, Pools: poolStatesToProto(node.Pools)})
+	}
+	for _, usage := range state.TopNamespaces {
+		resp.TopNamespaces = append(resp.TopNamespaces,
+			&extenderpb.NamespaceUsage{Namespace: usage.Namespace, Allocated: usage.Allocated})
+	}
+	return resp, nil
+}
+
+func poolStatesToProto(pools []PoolState) []*extenderpb.PoolState {
+	states := make([]*extenderpb.PoolState, 0, len(pools))
+	for _, pool := range pools {
+		states = append(states, &extenderpb.PoolState{
+			Pool:      string(pool.Pool),
+			Total:     pool.Total,
+			Reserved:  pool.Reserved,
+			Allocated: pool.Allocated,
+			Promised:  pool.Promised,
+			Free:      pool.Free,
+		})
+	}
+	return states
+}
+
+func (s *grpcExtender) ListPromises(
+	ctx context.Context, req *extenderpb.ListPromisesRequest,
+) (*extenderpb.ListPromisesResponse, error) {
+	resp := &extenderpb.ListPromisesResponse{}
+	for _, promised := range s.ext.promises.List() {
+		promise, err := promiseToProto(promised)
+		if err != nil {
+			return nil, err
+		}
+		resp.Promises = append(resp.Promises, promise)
+	}
+	return resp, nil
+}
+
+func (s *grpcExtender) MakePromise(ctx context.Context, req *extenderpb.MakePromiseRequest) (*extenderpb.Promise, error) {
+	if req.PodUid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "pod uid is required")
+	}
+	if req.Count < 1 || req.Count > maxPromiseCount {
+		return nil, status.Errorf(codes.InvalidArgument, "count should be between 1 and %d, got %d", maxPromiseCount, req.Count)
+	}
+	if req.PodNamespace == "" || req.PodName == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "pod namespace and name are required")
+	}
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	// promises of pods that don't exist would never be purged by binding
+	pod, err := client.Core().Pods(req.PodNamespace).Get(req.PodName, meta_v1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		return nil, status.Errorf(codes.NotFound, "pod %s/%s not found", req.PodNamespace, req.PodName)
+	case err != nil:
+		return nil, status.Errorf(codes.Unavailable, "error getting pod %s/%s: %v", req.PodNamespace, req.PodName, err)
+	case pod.UID != types.UID(req.PodUid):
+		return nil, status.Errorf(codes.FailedPrecondition, "pod %s/%s has uid %s, not %s",
+			req.PodNamespace, req.PodName, pod.UID, req.PodUid)
+	}
+	s.ext.promises.MakePromise(types.UID(req.PodUid), req.Count)
+	for _, promised := range s.ext.promises.List() {
+		if promised.UID == types.UID(req.PodUid) {
+			return promiseToProto(promised)
+		}
+	}
+	return nil, status.Errorf(codes.Aborted, "promise for %s was purged", req.PodUid)
+}
+
+func (s *grpcExtender) PurgePromise(
+	ctx context.Context, req *extenderpb.PurgePromiseRequest,
+) (*extenderpb.PurgePromiseResponse, error) {
+	if req.PodUid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "pod uid is required")
+	}
+	s.ext.promises.PurgePromise(types.UID(req.PodUid))
+	s.ext.events.forget(types.UID(req.PodUid))
+	return &extenderpb.PurgePromiseResponse{}, nil
+}
+
+func promiseToProto(promised PromiseStatus) (*extenderpb.Promise, error) {
+	made, err := ptypes.TimestampProto(promised.Made)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return &extenderpb.Promise{PodUid: string(promised.UID), Count: promised.Count, Made: made}, nil
+}
@@ -0,0 +1,154 @@
+package extender
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/extenderpb"
+)
+
+// startGRPC serves extender over an in-memory connection and returns a client for it.
+// Client connects without TLS unless transport credentials are passed in dial options.
+func startGRPC(t *testing.T, ext *Extender, opts SecurityOptions, dialOpts ...grpc.DialOption) (extenderpb.ExtenderClient, func()) {
+	srv, err := MakeGRPCServer(ext, opts)
+	require.NoError(t, err)
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	if len(dialOpts) == 0 {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+	dialOpts = append(dialOpts, grpc.WithDialer(func(string, time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	conn, err := grpc.Dial("bufnet", dialOpts...)
+	require.NoError(t, err)
+	return extenderpb.NewExtenderClient(conn), func() {
+		conn.Close()
+		srv.Stop()
+	}
+}
+
+func TestGRPCFilterAndPromises(t *testing.T) {
+	nodes := []v1.Node{makeNode(0, 2), makeNode(1, 0)}
+	for i := range nodes {
+		nodes[i].Status.Capacity = nodes[i].Status.Allocatable
+	}
+	reserved := makeVFPod("reserved", "", 1)
+	ext := NewExtender(fake.NewSimpleClientset(&nodes[0], &nodes[1], reserved))
+	client, stop := startGRPC(t, ext, SecurityOptions{})
+	defer stop()
+	ctx := context.Background()
+
+	pod, err := json.Marshal(makeVFPod("grpc", "", 2))
+	require.NoError(t, err)
+	node, err := json.Marshal(nodes[0])
+	require.NoError(t, err)
+	filtered, err := client.Filter(ctx, &extenderpb.ExtenderArgs{Pod: pod, Nodes: [][]byte{node}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"0"}, filtered.NodeNames)
+
+	promises, err := client.ListPromises(ctx, &extenderpb.ListPromisesRequest{})
+	require.NoError(t, err)
+	require.Len(t, promises.Promises, 1)
+	require.Equal(t, "grpc", promises.Promises[0].PodUid)
+	require.Equal(t, int64(2), promises.Promises[0].Count)
+
+	// promised VFs are not free anymore
+	priorities, err := client.Prioritize(ctx, &extenderpb.ExtenderArgs{Pod: pod, NodeNames: []string{"0"}})
+	require.NoError(t, err)
+	require.Equal(t, []*extenderpb.HostPriority{{Host: "0", Score: 0}}, priorities.Priorities)
+
+	_, err = client.PurgePromise(ctx, &extenderpb.PurgePromiseRequest{PodUid: "grpc"})
+	require.NoError(t, err)
+	promise, err := client.MakePromise(ctx, &extenderpb.MakePromiseRequest{
+		PodUid: "reserved", PodNamespace: "default", PodName: "reserved", Count: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "reserved", promise.PodUid)
+	require.NotNil(t, promise.Made)
+
+	state, err := client.GetState(ctx, &extenderpb.GetStateRequest{})
+	require.NoError(t, err)
+	require.Len(t, state.Nodes, 2)
+	require.Equal(t, int64(1), state.Pools[0].Promised)
+
+	for _, tc := range []struct {
+		req  *extenderpb.MakePromiseRequest
+		code codes.Code
+	}{
+		{&extenderpb.MakePromiseRequest{PodUid: "reserved", PodNamespace: "default", PodName: "reserved"}, codes.InvalidArgument},
+		{&extenderpb.MakePromiseRequest{PodUid: "reserved", PodNamespace: "default", PodName: "reserved", Count: maxPromiseCount + 1}, codes.InvalidArgument},
+		{&extenderpb.MakePromiseRequest{PodUid: "reserved", Count: 1}, codes.InvalidArgument},
+		{&extenderpb.MakePromiseRequest{PodUid: "missing", PodNamespace: "default", PodName: "missing", Count: 1}, codes.NotFound},
+		{&extenderpb.MakePromiseRequest{PodUid: "other", PodNamespace: "default", PodName: "reserved", Count: 1}, codes.FailedPrecondition},
+	} {
+		_, err = client.MakePromise(ctx, tc.req)
+		require.Equal(t, tc.code, grpc.Code(err), "request %v", tc.req)
+	}
+	_, err = client.Filter(ctx, &extenderpb.ExtenderArgs{Pod: []byte("{")})
+	require.Equal(t, codes.InvalidArgument, grpc.Code(err))
+}
+
+func TestGRPCWithoutClient(t *testing.T) {
+	client, stop := startGRPC(t, NewExtender(nil), SecurityOptions{})
+	defer stop()
+	ctx := context.Background()
+	_, err := client.GetState(ctx, &extenderpb.GetStateRequest{})
+	require.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+	pod, err := json.Marshal(makeVFPod("grpc", "", 1))
+	require.NoError(t, err)
+	_, err = client.Filter(ctx, &extenderpb.ExtenderArgs{Pod: pod})
+	require.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+	_, err = client.MakePromise(ctx, &extenderpb.MakePromiseRequest{
+		PodUid: "grpc", PodNamespace: "default", PodName: "grpc", Count: 1,
+	})
+	require.Equal(t, codes.FailedPrecondition, grpc.Code(err))
+}
+
+func TestRecoverGRPC(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/sriov.extender.v1.Extender/GetState"}
+	_, err := recoverGRPC(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		panic("broken")
+	})
+	require.Equal(t, codes.Internal, grpc.Code(err))
+}
+
+func TestGRPCToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extender-grpc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ca := writeCert(t, dir, "ca", nil)
+	writeCert(t, dir, "server", ca)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("secret"), 0600))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	client, stop := startGRPC(t, NewExtender(nil), SecurityOptions{
+		CertFile:  filepath.Join(dir, "server.crt"),
+		KeyFile:   filepath.Join(dir, "server.key"),
+		TokenFile: filepath.Join(dir, "token"),
+	}, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: roots, ServerName: "127.0.0.1"})))
+	defer stop()
+	_, err = client.ListPromises(context.Background(), &extenderpb.ListPromisesRequest{})
+	require.Equal(t, codes.Unauthenticated, grpc.Code(err))
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret"))
+	_, err = client.ListPromises(ctx, &extenderpb.ListPromisesRequest{})
+	require.NoError(t, err)
+}
@@ -3,53 +3,189 @@ package extender
 import (
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/apimachinery/pkg/api/errors"
 )
 
 func MakeServer(ext *Extender, addr string) *http.Server {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/filter", MakeHandler(ext.FilterArgs))
-	mux.HandleFunc("/prioritize", MakeHandler(ext.Prioritize))
+	protocol := ext.protocol
+	mux.HandleFunc("/filter", instrument("filter", protocol.MakeHandler(ext.FilterArgs)))
+	mux.HandleFunc("/prioritize", instrument("prioritize", protocol.MakeHandler(ext.Prioritize)))
+	if protocol.Preemption {
+		mux.HandleFunc("/preempt", instrument("preempt", protocol.MakePreemptionHandler(ext.ProcessPreemption)))
+	}
+	if protocol.Binding {
+		mux.HandleFunc("/bind", instrument("bind", protocol.MakeBindingHandler(ext.Bind)))
+	}
+	mux.HandleFunc("/simulate", instrument("simulate", MakeSimulateHandler(ext.Simulate)))
+	mux.HandleFunc("/state", instrument("state", MakeStateHandler(ext.State)))
+	mux.HandleFunc("/explain", instrument("explain", MakeExplainHandler(ext.Explain)))
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", MakeHealthHandler(ext.Healthy))
+	mux.HandleFunc("/readyz", MakeHealthHandler(ext.Ready))
 	srv := &http.Server{
 		Addr:         addr,
-		Handler:      mux,
+		Handler:      recoverPanics(mux),
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
 	}
 	return srv
 }
 
+// MakeHandler serves filter or prioritize verb with DefaultAPIVersion of the protocol.
 func MakeHandler(f func(*ExtenderArgs) (interface{}, error)) http.HandlerFunc {
+	return protocols[DefaultAPIVersion].MakeHandler(f)
+}
+
+// MakeHandler serves filter or prioritize verb and encodes result for the protocol revision.
+func (p *Protocol) MakeHandler(f func(*ExtenderArgs) (interface{}, error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodPost {
-			var args ExtenderArgs
-			decoder := json.NewDecoder(r.Body)
-			if err := decoder.Decode(&args); err != nil {
-				if err != io.EOF {
-					log.Printf("error unmarshalling body: %v\n", err)
-					http.Error(w, err.Error(), http.StatusBadRequest)
-					return
-				}
-			}
-			if result, err := f(&args); err != nil {
-				log.Printf("error running filter: %v\n", err)
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-			} else {
-				body, err := json.Marshal(result)
-				if err != nil {
-					log.Printf("error marshalling result: %v\n", err)
-					http.Error(w, err.Error(), http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(200)
-				if _, err := w.Write(body); err != nil {
-					log.Printf("error writing response body: %v", err)
-				}
+		var args ExtenderArgs
+		handleJSON(w, r, &args, func() (interface{}, error) {
+			return p.encodeResult(f(&args))
+		})
+	}
+}
+
+func (p *Protocol) MakePreemptionHandler(f func(*ExtenderPreemptionArgs) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var args ExtenderPreemptionArgs
+		handleJSON(w, r, &args, func() (interface{}, error) {
+			return p.encodeResult(f(&args))
+		})
+	}
+}
+
+func (p *Protocol) MakeBindingHandler(f func(*ExtenderBindingArgs) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var args ExtenderBindingArgs
+		handleJSON(w, r, &args, func() (interface{}, error) {
+			return p.encodeResult(f(&args))
+		})
+	}
+}
+
+func MakeSimulateHandler(f func(*SimulateArgs) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var args SimulateArgs
+		handleJSON(w, r, &args, func() (interface{}, error) {
+			return f(&args)
+		})
+	}
+}
+
+// MakeStateHandler reports cluster state as JSON or as a table if format=table is requested.
+func MakeStateHandler(f func() (*ClusterState, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed, "invalid request method %s", r.Method)
+			return
+		}
+		state, err := f()
+		if err != nil {
+			log.Printf("error collecting state: %v\n", err)
+			writeError(w, http.StatusInternalServerError, ErrorCodeInternal, "%v", err)
+			return
+		}
+		if r.URL.Query().Get("format") == "table" {
+			w.Header().Set("Content-Type", "text/plain")
+			if err := WriteStateTable(w, state); err != nil {
+				log.Printf("error writing response body: %v", err)
 			}
-		} else {
-			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(state); err != nil {
+			log.Printf("error writing response body: %v", err)
 		}
 	}
 }
+
+// MakeExplainHandler reports why a pod given as pod=namespace/name fits or doesn't fit every node.
+func MakeExplainHandler(f func(namespace, name string) (*Explanation, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed, "invalid request method %s", r.Method)
+			return
+		}
+		parts := strings.Split(r.URL.Query().Get("pod"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			writeError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "pod should be given as namespace/name")
+			return
+		}
+		explanation, err := f(parts[0], parts[1])
+		if errors.IsNotFound(err) {
+			writeError(w, http.StatusNotFound, ErrorCodeNotFound, "%v", err)
+			return
+		} else if err != nil {
+			log.Printf("error explaining pod %s/%s: %v\n", parts[0], parts[1], err)
+			writeError(w, http.StatusInternalServerError, ErrorCodeInternal, "%v", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(explanation); err != nil {
+			log.Printf("error writing response body: %v", err)
+		}
+	}
+}
+
+// maxRequestBytes limits size of a request body, it fits a list of a few thousands of nodes.
+var maxRequestBytes int64 = 64 << 20
+
+// handleJSON decodes and validates request body, calls f and writes its result as JSON.
+func handleJSON(w http.ResponseWriter, r *http.Request, args validator, f func() (interface{}, error)) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed, "invalid request method %s", r.Method)
+		return
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestBytes+1))
+	if err != nil {
+		log.Printf("error reading body: %v\n", err)
+		writeError(w, http.StatusBadRequest, ErrorCodeMalformedRequest, "error reading body: %v", err)
+		return
+	}
+	if int64(len(data)) > maxRequestBytes {
+		log.Printf("request to %s is larger than %d bytes\n", r.URL.Path, maxRequestBytes)
+		writeError(w, http.StatusRequestEntityTooLarge, ErrorCodeRequestTooLarge,
+			"request body is larger than %d bytes", maxRequestBytes)
+		return
+	}
+	if len(data) == 0 {
+		writeError(w, http.StatusBadRequest, ErrorCodeMalformedRequest, "request body is empty")
+		return
+	}
+	if err := json.Unmarshal(data, args); err != nil {
+		log.Printf("error unmarshalling body: %v\n", err)
+		writeError(w, http.StatusBadRequest, ErrorCodeMalformedRequest, "error unmarshalling body: %v", err)
+		return
+	}
+	if err := args.validate(); err != nil {
+		log.Printf("invalid request to %s: %v\n", r.URL.Path, err)
+		writeError(w, http.StatusBadRequest, ErrorCodeInvalidRequest, "%v", err)
+		return
+	}
+	result, err := f()
+	if err != nil {
+		log.Printf("error running %s: %v\n", r.URL.Path, err)
+		writeError(w, http.StatusInternalServerError, ErrorCodeInternal, "%v", err)
+		return
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("error marshalling result: %v\n", err)
+		writeError(w, http.StatusInternalServerError, ErrorCodeInternal, "error marshalling result: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+	if _, err := w.Write(body); err != nil {
+		log.Printf("error writing response body: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package extender
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleJSON(t *testing.T) {
+	defer func(max int64) { maxRequestBytes = max }(maxRequestBytes)
+	maxRequestBytes = 256
+	srv := httptest.NewServer(MakeServer(NewExtender(nil), "").Handler)
+	defer srv.Close()
+
+	for i, tc := range []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   ErrorCode
+	}{
+		{"GET", "/filter", "", http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed},
+		{"POST", "/filter", "", http.StatusBadRequest, ErrorCodeMalformedRequest},
+		{"POST", "/filter", `{"Pod":`, http.StatusBadRequest, ErrorCodeMalformedRequest},
+		{"POST", "/filter", `{"Nodes": {"items": []}}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{"POST", "/filter", `{"Pod": {"metadata": {"uid": "1"}}}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{"POST", "/filter", `{"Pod": {"metadata": {"uid": "1"}}, "Nodes": {"items": [` +
+			strings.Repeat(`{},`, 100) + `{}]}}`, http.StatusRequestEntityTooLarge, ErrorCodeRequestTooLarge},
+		{"POST", "/filter", `{"Pod": {"metadata": {"uid": "1", "annotations": {"networks": "sriov"}}}, "NodeNames": []}`,
+			http.StatusInternalServerError, ErrorCodeInternal},
+		{"POST", "/filter", `{"Pod": {"metadata": {"uid": "1"}}, "Nodes": {"items": []}}`, http.StatusOK, ""},
+		{"POST", "/simulate", `{}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{"POST", "/simulate", `{"Replicas": -1}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+		{"POST", "/simulate", `{"Replicas": 1001}`, http.StatusBadRequest, ErrorCodeInvalidRequest},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, tc.status, resp.StatusCode)
+			if tc.code == "" {
+				return
+			}
+			var errResp ErrorResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+			require.Equal(t, tc.code, errResp.Code)
+			require.NotEmpty(t, errResp.Message)
+		})
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	w := httptest.NewRecorder()
+	recoverPanics(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("handler failed")
+	})).ServeHTTP(w, httptest.NewRequest("POST", "/filter", nil))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.JSONEq(t, `{"Code": "Internal", "Message": "internal error serving /filter"}`, w.Body.String())
+}
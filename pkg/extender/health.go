@@ -0,0 +1,98 @@
+package extender
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultReadyTimeout allows pod monitor to miss a few resyncs before extender is not ready.
+	defaultReadyTimeout = 4 * podsResyncPeriod
+	// missedCleanerTicks is a number of promises cleaner ticks that can be missed by a healthy extender.
+	missedCleanerTicks = 3
+)
+
+// health tracks background loops of the extender.
+// It has its own lock because filter keeps the extender locked while waiting for promises.
+type health struct {
+	sync.Mutex
+	podsSynced      func() bool
+	podsUpdatedAt   time.Time
+	readyTimeout    time.Duration
+	cleanerInterval time.Duration
+}
+
+func (h *health) setPodsSynced(synced func() bool) {
+	h.Lock()
+	defer h.Unlock()
+	h.podsSynced = synced
+}
+
+func (h *health) podsUpdated() {
+	h.Lock()
+	defer h.Unlock()
+	h.podsUpdatedAt = time.Now()
+}
+
+func (h *health) setCleanerInterval(interval time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+	h.cleanerInterval = interval
+}
+
+// SetReadyTimeout sets how long pod monitor can go without updates before extender is not ready.
+func (ext *Extender) SetReadyTimeout(timeout time.Duration) {
+	ext.health.Lock()
+	defer ext.health.Unlock()
+	ext.health.readyTimeout = timeout
+}
+
+// Ready returns an error unless pod monitor is synced and was recently updated.
+// Informer resyncs pods periodically, so a monitor without updates has stalled.
+func (ext *Extender) Ready() error {
+	ext.health.Lock()
+	defer ext.health.Unlock()
+	if ext.health.podsSynced == nil {
+		return fmt.Errorf("pod monitor is not running")
+	}
+	if !ext.health.podsSynced() {
+		return fmt.Errorf("pod monitor is not synced")
+	}
+	if since := time.Since(ext.health.podsUpdatedAt); since > ext.health.readyTimeout {
+		return fmt.Errorf("pod monitor was not updated for %v", since)
+	}
+	return nil
+}
+
+// Healthy returns an error unless promises cleaner keeps ticking.
+func (ext *Extender) Healthy() error {
+	ext.health.Lock()
+	interval := ext.health.cleanerInterval
+	ext.health.Unlock()
+	if interval == 0 {
+		return fmt.Errorf("promises cleaner is not running")
+	}
+	cleaned := ext.promises.LastCleaned()
+	if cleaned.IsZero() {
+		return fmt.Errorf("promises cleaner is not running")
+	}
+	if since := time.Since(cleaned); since > missedCleanerTicks*interval {
+		return fmt.Errorf("promises cleaner last ran %v ago", since)
+	}
+	return nil
+}
+
+// MakeHealthHandler responds with 200 if check passes and 503 with the error otherwise.
+func MakeHealthHandler(check func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := check(); err != nil {
+			log.Printf("%s failed: %v\n", r.URL.Path, err)
+			writeError(w, http.StatusServiceUnavailable, ErrorCodeUnavailable, "%v", err)
+			return
+		}
+		w.Write([]byte("ok"))
+	}
+}
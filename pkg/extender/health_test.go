@@ -0,0 +1,61 @@
+package extender
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	fake "k8s.io/client-go/tools/cache/testing"
+)
+
+var errCheckFailed = errors.New("check failed")
+
+func TestReady(t *testing.T) {
+	ext := NewExtender(nil)
+	require.Error(t, ext.Ready())
+
+	ctl := ext.createMonitorFromSource(fake.NewFakeControllerSource())
+	require.Error(t, ext.Ready())
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go ctl.Run(stopCh)
+	Eventually(t, ext.Ready, 100*time.Millisecond, 2*time.Millisecond)
+
+	ext.SetReadyTimeout(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	require.Error(t, ext.Ready())
+}
+
+func TestHealthy(t *testing.T) {
+	ext := NewExtender(nil)
+	require.Error(t, ext.Healthy())
+
+	stopCh := make(chan struct{})
+	go ext.RunPromisesCleaner(time.Millisecond, stopCh)
+	Eventually(t, ext.Healthy, 100*time.Millisecond, 2*time.Millisecond)
+
+	close(stopCh)
+	Eventually(t, func() error {
+		if ext.Healthy() == nil {
+			return errCheckFailed
+		}
+		return nil
+	}, 100*time.Millisecond, 2*time.Millisecond)
+}
+
+func TestHealthHandler(t *testing.T) {
+	for i, tc := range []struct {
+		err  error
+		code int
+	}{
+		{nil, http.StatusOK},
+		{errCheckFailed, http.StatusServiceUnavailable},
+	} {
+		w := httptest.NewRecorder()
+		MakeHealthHandler(func() error { return tc.err })(w, httptest.NewRequest("GET", "/healthz", nil))
+		require.Equal(t, tc.code, w.Code, "case %d", i)
+	}
+}
@@ -0,0 +1,37 @@
+package extender
+
+import (
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+// linkDownCondition returns PFLinkDownCondition of a node if it is true.
+func linkDownCondition(node *v1.Node) (*v1.NodeCondition, bool) {
+	for i := range node.Status.Conditions {
+		condition := &node.Status.Conditions[i]
+		if condition.Type == nodestate.PFLinkDownCondition {
+			return condition, condition.Status == v1.ConditionTrue
+		}
+	}
+	return nil, false
+}
+
+// allLinksDown returns a failure reason if links of all PFs of a node are down.
+// Discovery reports VFs of such PFs as not allocatable, but the condition explains why.
+func allLinksDown(node *v1.Node) (string, bool) {
+	condition, down := linkDownCondition(node)
+	if !down || condition.Reason != nodestate.AllPFLinksDownReason {
+		return "", false
+	}
+	return condition.Message, true
+}
+
+// explainLinks appends PFs with links down to a failure reason of a node, VFs of these PFs
+// are not allocatable.
+func explainLinks(node *v1.Node, reason string) string {
+	if condition, down := linkDownCondition(node); down && len(reason) != 0 {
+		return reason + ". " + condition.Message
+	}
+	return reason
+}
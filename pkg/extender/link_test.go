@@ -0,0 +1,67 @@
+package extender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+func setLinkDown(node *v1.Node, reason, message string) {
+	node.Status.Conditions = append(node.Status.Conditions, v1.NodeCondition{
+		Type: nodestate.PFLinkDownCondition, Status: v1.ConditionTrue, Reason: reason, Message: message})
+}
+
+func TestFilterLinkDown(t *testing.T) {
+	ext := NewExtender(nil)
+	args := makeExtenderArgs([]int64{2, 0, 2, 2})
+	// discovery reports VFs of PFs with links down as not allocatable
+	setLinkDown(&args.Nodes.Items[0], nodestate.AllPFLinksDownReason, "Links of all PFs are down: ens1f0")
+	setLinkDown(&args.Nodes.Items[1], nodestate.PFLinkDownReason, "Links of PFs are down: ens1f1")
+	args.Nodes.Items[2].Status.Conditions = []v1.NodeCondition{{
+		Type: nodestate.PFLinkDownCondition, Status: v1.ConditionFalse, Reason: nodestate.PFLinksUpReason}}
+	resultInterface, err := ext.FilterArgs(args)
+	require.NoError(t, err)
+	result := resultInterface.(*ExtenderFilterResult)
+	require.Len(t, result.Nodes.Items, 2)
+	require.Equal(t, FailedNodesMap{"0": "Links of all PFs are down: ens1f0"}, result.FailedAndUnresolvableNodes)
+	require.Equal(t, FailedNodesMap{
+		"1": "Not sufficient number of totalvfs. Allocated: 0. Promised: 0. Total: 0. Links of PFs are down: ens1f1",
+	}, result.FailedNodes)
+
+	explained := ext.explain(VFRequest{Count: 1}, topology{}, args.Nodes.Items)
+	require.Equal(t, RuleLinkDown, explained[0].Rule)
+	require.Equal(t, "Links of all PFs are down: ens1f0", explained[0].Reason)
+	require.Equal(t, RuleInsufficientVFs, explained[1].Rule)
+}
+
+func TestFilterTopologyLinkDown(t *testing.T) {
+	ext := NewExtender(nil)
+	down := makeStatePF("ens1f0", 0, netdevVF, netdevVF)
+	down.LinkDown = true
+	ext.SetNodeStates(fakeNodeStates{
+		"0": makeNodeState(down, makeStatePF("ens1f1", 0, netdevVF, netdevVF)),
+		"1": makeNodeState(down),
+	})
+	args := makeExtenderArgs([]int64{4, 4})
+	args.Pod.Annotations[PFAnnotation] = "ens1f0"
+	resultInterface, err := ext.FilterArgs(args)
+	require.NoError(t, err)
+	result := resultInterface.(*ExtenderFilterResult)
+	require.Empty(t, result.Nodes.Items)
+	require.Equal(t, FailedNodesMap{
+		"0": "Links of all PFs ens1f0 are down",
+		"1": "Links of all PFs ens1f0 are down",
+	}, result.FailedAndUnresolvableNodes)
+
+	args.Pod.Annotations[PFAnnotation] = "ens1f*"
+	args.Nodes.Items[0].Status.Allocatable[TotalVFsResource] = *resource.NewQuantity(2, resource.DecimalSI)
+	resultInterface, err = ext.FilterArgs(args)
+	require.NoError(t, err)
+	result = resultInterface.(*ExtenderFilterResult)
+	require.Len(t, result.Nodes.Items, 1)
+	require.Equal(t, "0", result.Nodes.Items[0].Name)
+}
@@ -0,0 +1,129 @@
+package extender
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const (
+	metricsNamespace = "sriov"
+	metricsSubsystem = "extender"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "requests_total",
+		Help:      "Number of requests handled by the extender per verb and response code.",
+	}, []string{"verb", "code"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "request_duration_seconds",
+		Help:      "Latency of requests handled by the extender per verb.",
+	}, []string{"verb"})
+	filterNodesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "filter_nodes_total",
+		Help:      "Number of nodes that passed or failed filter.",
+	}, []string{"result"})
+	filterErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "filter_errors_total",
+		Help:      "Number of filter calls that didn't find any node with available VFs.",
+	})
+	promiseWaitSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "promise_wait_seconds",
+		Help:      "Time filter spent waiting for promised VFs to be released.",
+	})
+	promisesOutstanding = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "promises_outstanding",
+		Help:      "Number of pods with promised VFs.",
+	})
+	promiseExpirationsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "promise_expirations_total",
+		Help:      "Number of promises purged by the cleaner.",
+	})
+	nodeAllocatedVFs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "node_allocated_vfs",
+		Help:      "Number of VFs allocated on a node per pool.",
+	}, []string{"node", "pool"})
+	nodeFreeVFs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "node_free_vfs",
+		Help:      "Number of VFs neither allocated nor promised on a node per pool, as of the last filter or prioritize.",
+	}, []string{"node", "pool"})
+	monitorEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "monitor_events_total",
+		Help:      "Number of pod events handled by the pod monitor.",
+	}, []string{"event"})
+	driftCorrectionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "drift_corrections_total",
+		Help:      "Number of nodes which accounting was corrected by reconciliation.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		requestsTotal, requestDuration,
+		filterNodesTotal, filterErrorsTotal,
+		promiseWaitSeconds, promisesOutstanding, promiseExpirationsTotal,
+		nodeAllocatedVFs, nodeFreeVFs,
+		monitorEventsTotal, driftCorrectionsTotal,
+	)
+}
+
+// statusRecorder remembers response code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// instrument counts requests and measures their latency for a verb.
+func instrument(verb string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		h(recorder, r)
+		requestDuration.WithLabelValues(verb).Observe(time.Since(start).Seconds())
+		requestsTotal.WithLabelValues(verb, strconv.Itoa(recorder.code)).Inc()
+	}
+}
+
+// observeNode updates allocated and free VFs gauges of every pool of a request.
+func observeNode(node *v1.Node, req VFRequest, allocated v1.ResourceList, promised *resource.Quantity) {
+	for _, resName := range req.Resources() {
+		used := allocated[resName]
+		nodeAllocatedVFs.WithLabelValues(node.Name, string(resName)).Set(float64(used.Value()))
+		if res, exists := node.Status.Allocatable[resName]; exists {
+			free := res.Value() - used.Value() - promised.Value()
+			nodeFreeVFs.WithLabelValues(node.Name, string(resName)).Set(float64(free))
+		}
+	}
+}
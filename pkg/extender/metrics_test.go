@@ -0,0 +1,86 @@
+package extender
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+func TestInstrument(t *testing.T) {
+	requests, count := instrumentedTotals(t)
+	handler := instrument("test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/test", nil))
+	// metrics are global, compare with values before the call so the test can be repeated
+	requestsAfter, countAfter := instrumentedTotals(t)
+	require.Equal(t, requests+1, requestsAfter)
+	require.Equal(t, count+1, countAfter)
+
+	srv := httptest.NewServer(MakeServer(NewExtender(nil), "").Handler)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(body), `sriov_extender_requests_total{code="400",verb="test"}`))
+	require.True(t, strings.Contains(string(body), `sriov_extender_request_duration_seconds_count{verb="test"}`))
+}
+
+// instrumentedTotals returns number of requests with code 400 and number of observed
+// durations of the test verb.
+func instrumentedTotals(t *testing.T) (float64, uint64) {
+	requests := &dto.Metric{}
+	require.NoError(t, requestsTotal.WithLabelValues("test", "400").Write(requests))
+	duration := &dto.Metric{}
+	require.NoError(t, requestDuration.WithLabelValues("test").Write(duration))
+	return requests.GetCounter().GetValue(), duration.GetHistogram().GetSampleCount()
+}
+
+func TestAllocatedMetrics(t *testing.T) {
+	ext := NewExtender(nil)
+	pod := makeVFPod("metrics", "metrics-node", 3)
+	req, _, _ := ext.selector(pod)
+	ext.allocate(pod, req)
+	require.Equal(t, 3.0, gaugeValue(t, "metrics-node", string(TotalVFsResource)))
+	ext.release(pod.UID)
+	require.Equal(t, 0.0, gaugeValue(t, "metrics-node", string(TotalVFsResource)))
+}
+
+func gaugeValue(t *testing.T, labels ...string) float64 {
+	gauge, err := nodeAllocatedVFs.GetMetricWithLabelValues(labels...)
+	require.NoError(t, err)
+	metric := &dto.Metric{}
+	require.NoError(t, gauge.Write(metric))
+	return metric.GetGauge().GetValue()
+}
+
+func filterNodesValue(t *testing.T, result string) float64 {
+	metric := &dto.Metric{}
+	require.NoError(t, filterNodesTotal.WithLabelValues(result).Write(metric))
+	return metric.GetCounter().GetValue()
+}
+
+func TestFilterNodesMetric(t *testing.T) {
+	ext := NewExtender(nil)
+	ext.SetNodeStates(fakeNodeStates{"2": makeNodeState(makeStatePF("ens1f0", 0, netdevVF))})
+	args := makeExtenderArgs([]int64{2, 2, 2, 2})
+	args.Pod.Annotations[PFAnnotation] = "ens1f*"
+	// links of all PFs are down, node has no VFs, topology matches and topology doesn't match
+	setLinkDown(&args.Nodes.Items[0], nodestate.AllPFLinksDownReason, "Links of all PFs are down: ens1f0")
+	delete(args.Nodes.Items[1].Status.Allocatable, TotalVFsResource)
+	failed, passed := filterNodesValue(t, "failed"), filterNodesValue(t, "passed")
+	_, err := ext.FilterArgs(args)
+	require.NoError(t, err)
+	require.Equal(t, failed+3, filterNodesValue(t, "failed"))
+	require.Equal(t, passed+1, filterNodesValue(t, "passed"))
+}
@@ -0,0 +1,229 @@
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// MultusNetworksAnnotation lists networks pod has to be attached to.
+	MultusNetworksAnnotation = "k8s.v1.cni.cncf.io/networks"
+	// ResourceNameAnnotation on a NetworkAttachmentDefinition names a pool VFs are allocated from.
+	ResourceNameAnnotation = "k8s.v1.cni.cncf.io/resourceName"
+	sriovCNIType           = "sriov"
+)
+
+var networkAttachmentGroupVersion = schema.GroupVersion{Group: "k8s.cni.cncf.io", Version: "v1"}
+
+// NetworkSelectionElement is a reference to a network in the multus annotation.
+type NetworkSelectionElement struct {
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace,omitempty"`
+	InterfaceRequest string `json:"interface,omitempty"`
+}
+
+// ParseNetworksAnnotation parses both formats of the multus networks annotation:
+// a comma separated list of [namespace/]name[@interface] and a JSON list of
+// NetworkSelectionElement. Networks without namespace get a namespace of the pod.
+func ParseNetworksAnnotation(networks, podNamespace string) ([]NetworkSelectionElement, error) {
+	var elements []NetworkSelectionElement
+	networks = strings.TrimSpace(networks)
+	if strings.HasPrefix(networks, "[") {
+		if err := json.Unmarshal([]byte(networks), &elements); err != nil {
+			return nil, fmt.Errorf("error parsing networks %s: %v", networks, err)
+		}
+	} else {
+		for _, item := range strings.Split(networks, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			var element NetworkSelectionElement
+			if parts := strings.SplitN(item, "@", 2); len(parts) == 2 {
+				item, element.InterfaceRequest = parts[0], parts[1]
+			}
+			if parts := strings.SplitN(item, "/", 2); len(parts) == 2 {
+				element.Namespace, element.Name = parts[0], parts[1]
+			} else {
+				element.Name = item
+			}
+			elements = append(elements, element)
+		}
+	}
+	for i := range elements {
+		if elements[i].Name == "" {
+			return nil, fmt.Errorf("network without a name in %s", networks)
+		}
+		if elements[i].Namespace == "" {
+			elements[i].Namespace = podNamespace
+		}
+	}
+	return elements, nil
+}
+
+// NetworkAttachment is a part of NetworkAttachmentDefinition relevant for VFs scheduling.
+type NetworkAttachment struct {
+	// SRIOV is true if network is configured with SR-IOV CNI plugin.
+	SRIOV bool
+	// Pool VFs are allocated from, empty if definition doesn't name any.
+	Pool v1.ResourceName
+	// Mode of the driver VF has to be bound to.
+	Mode DriverMode
+}
+
+// NetworkAttachments resolves network attachment definitions.
+type NetworkAttachments interface {
+	// Get returns network attachment and false if definition doesn't exist.
+	Get(namespace, name string) (NetworkAttachment, bool, error)
+}
+
+// MultusSelector resolves networks referenced by the multus annotation and
+// requests a VF for every SR-IOV network. All SR-IOV networks of a pod must use
+// the same pool and driver mode.
+func MultusSelector(attachments NetworkAttachments) Selector {
+	return func(pod *v1.Pod) (VFRequest, bool, error) {
+		networks, exists := pod.Annotations[MultusNetworksAnnotation]
+		if !exists {
+			return VFRequest{}, false, nil
+		}
+		elements, err := ParseNetworksAnnotation(networks, pod.Namespace)
+		if err != nil {
+			log.Printf("Pod %s/%s has invalid networks annotation: %v", pod.Namespace, pod.Name, err)
+			return VFRequest{}, false, nil
+		}
+		var req VFRequest
+		for _, element := range elements {
+			attachment, exists, err := attachments.Get(element.Namespace, element.Name)
+			if err != nil {
+				log.Printf("Error resolving network %s/%s: %v", element.Namespace, element.Name, err)
+				continue
+			}
+			if !exists {
+				log.Printf("Network %s/%s requested by pod %s/%s doesn't exist",
+					element.Namespace, element.Name, pod.Namespace, pod.Name)
+				continue
+			}
+			if !attachment.SRIOV {
+				continue
+			}
+			if req.Count != 0 && (req.Pool != attachment.Pool || req.Mode != attachment.Mode) {
+				return VFRequest{}, false, fmt.Errorf(
+					"pod %s/%s requests VFs from different pools or driver modes, a single one is supported",
+					pod.Namespace, pod.Name)
+			}
+			req.Pool, req.Mode = attachment.Pool, attachment.Mode
+			req.Count++
+		}
+		return req, req.Count > 0, nil
+	}
+}
+
+// NetworkAttachmentFromUnstructured reads network attachment from a NetworkAttachmentDefinition.
+// CNI configuration of SR-IOV network has type sriov and dpdk section if VF is used by DPDK.
+func NetworkAttachmentFromUnstructured(obj *unstructured.Unstructured) (NetworkAttachment, error) {
+	attachment := NetworkAttachment{Pool: v1.ResourceName(obj.GetAnnotations()[ResourceNameAnnotation])}
+	spec, _ := obj.Object["spec"].(map[string]interface{})
+	config, _ := spec["config"].(string)
+	if config == "" {
+		return attachment, nil
+	}
+	var cniConfig struct {
+		Type    string                   `json:"type"`
+		DPDK    *json.RawMessage         `json:"dpdk"`
+		Plugins []map[string]interface{} `json:"plugins"`
+	}
+	if err := json.Unmarshal([]byte(config), &cniConfig); err != nil {
+		return attachment, fmt.Errorf("error parsing config of network %s/%s: %v", obj.GetNamespace(), obj.GetName(), err)
+	}
+	attachment.SRIOV = cniConfig.Type == sriovCNIType
+	for _, plugin := range cniConfig.Plugins {
+		if plugin["type"] == sriovCNIType {
+			attachment.SRIOV = true
+			if _, dpdk := plugin["dpdk"]; dpdk {
+				attachment.Mode = DriverModeDPDK
+			}
+		}
+	}
+	if cniConfig.DPDK != nil {
+		attachment.Mode = DriverModeDPDK
+	}
+	return attachment, nil
+}
+
+// NetworkAttachmentsInformer keeps NetworkAttachmentDefinitions cached by an informer.
+type NetworkAttachmentsInformer struct {
+	store      cache.Store
+	controller cache.Controller
+}
+
+// NewNetworkAttachmentsInformer creates informer for NetworkAttachmentDefinitions in all namespaces.
+func NewNetworkAttachmentsInformer(config *rest.Config) (*NetworkAttachmentsInformer, error) {
+	nadConfig := *config
+	nadConfig.APIPath = "/apis"
+	nadConfig.GroupVersion = &networkAttachmentGroupVersion
+	client, err := dynamic.NewClient(&nadConfig)
+	if err != nil {
+		return nil, err
+	}
+	resource := client.Resource(&meta_v1.APIResource{
+		Name:       "network-attachment-definitions",
+		Namespaced: true,
+	}, meta_v1.NamespaceAll)
+	lw := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return resource.List(options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return resource.Watch(options)
+		},
+	}
+	return newNetworkAttachmentsInformerFromSource(lw), nil
+}
+
+func newNetworkAttachmentsInformerFromSource(lw cache.ListerWatcher) *NetworkAttachmentsInformer {
+	store, controller := cache.NewInformer(
+		lw, &unstructured.Unstructured{}, 30*time.Second, cache.ResourceEventHandlerFuncs{})
+	return &NetworkAttachmentsInformer{store: store, controller: controller}
+}
+
+func (i *NetworkAttachmentsInformer) Run(stopCh <-chan struct{}) {
+	i.controller.Run(stopCh)
+}
+
+func (i *NetworkAttachmentsInformer) HasSynced() bool {
+	return i.controller.HasSynced()
+}
+
+// Pools returns pools named by SR-IOV network attachment definitions.
+func (i *NetworkAttachmentsInformer) Pools() []v1.ResourceName {
+	pools := []v1.ResourceName{}
+	for _, obj := range i.store.List() {
+		attachment, err := NetworkAttachmentFromUnstructured(obj.(*unstructured.Unstructured))
+		if err == nil && attachment.SRIOV && attachment.Pool != "" {
+			pools = append(pools, attachment.Pool)
+		}
+	}
+	return pools
+}
+
+func (i *NetworkAttachmentsInformer) Get(namespace, name string) (NetworkAttachment, bool, error) {
+	obj, exists, err := i.store.GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return NetworkAttachment{}, exists, err
+	}
+	attachment, err := NetworkAttachmentFromUnstructured(obj.(*unstructured.Unstructured))
+	return attachment, true, err
+}
@@ -0,0 +1,188 @@
+package extender
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/pkg/api/v1"
+	fake "k8s.io/client-go/tools/cache/testing"
+)
+
+func TestParseNetworksAnnotation(t *testing.T) {
+	testCases := []struct {
+		networks string
+		expected []NetworkSelectionElement
+		error    bool
+	}{
+		{
+			networks: "sriov-a, other/sriov-b@net2",
+			expected: []NetworkSelectionElement{
+				{Name: "sriov-a", Namespace: "default"},
+				{Name: "sriov-b", Namespace: "other", InterfaceRequest: "net2"},
+			},
+		},
+		{
+			networks: `[{"name": "sriov-a"}, {"name": "sriov-b", "namespace": "other", "interface": "net2"}]`,
+			expected: []NetworkSelectionElement{
+				{Name: "sriov-a", Namespace: "default"},
+				{Name: "sriov-b", Namespace: "other", InterfaceRequest: "net2"},
+			},
+		},
+		{
+			networks: `[{"namespace": "other"}]`,
+			error:    true,
+		},
+		{
+			networks: `[{"name": "sriov-a"`,
+			error:    true,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			elements, err := ParseNetworksAnnotation(tc.networks, "default")
+			if tc.error {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, elements)
+		})
+	}
+}
+
+func makeNetworkAttachmentDefinition(namespace, name, pool, config string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "k8s.cni.cncf.io/v1",
+		"kind":       "NetworkAttachmentDefinition",
+		"spec":       map[string]interface{}{"config": config},
+	}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetResourceVersion("1")
+	if pool != "" {
+		obj.SetAnnotations(map[string]string{ResourceNameAnnotation: pool})
+	}
+	return obj
+}
+
+func TestNetworkAttachmentFromUnstructured(t *testing.T) {
+	testCases := []struct {
+		pool     string
+		config   string
+		expected NetworkAttachment
+	}{
+		{
+			config:   `{"type": "sriov", "master": "eth0"}`,
+			expected: NetworkAttachment{SRIOV: true},
+		},
+		{
+			pool:     "pool-a",
+			config:   `{"type": "sriov", "dpdk": {"kernel_driver": "ixgbevf", "dpdk_driver": "vfio-pci"}}`,
+			expected: NetworkAttachment{SRIOV: true, Pool: "pool-a", Mode: DriverModeDPDK},
+		},
+		{
+			config:   `{"cniVersion": "0.3.1", "plugins": [{"type": "sriov"}, {"type": "tuning"}]}`,
+			expected: NetworkAttachment{SRIOV: true},
+		},
+		{
+			config:   `{"type": "calico"}`,
+			expected: NetworkAttachment{},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			attachment, err := NetworkAttachmentFromUnstructured(
+				makeNetworkAttachmentDefinition("default", "net", tc.pool, tc.config))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, attachment)
+		})
+	}
+}
+
+type fakeNetworkAttachments map[string]NetworkAttachment
+
+func (f fakeNetworkAttachments) Get(namespace, name string) (NetworkAttachment, bool, error) {
+	attachment, exists := f[namespace+"/"+name]
+	return attachment, exists, nil
+}
+
+func TestMultusSelector(t *testing.T) {
+	selector := MultusSelector(fakeNetworkAttachments{
+		"default/sriov-a":  {SRIOV: true, Pool: "pool-a"},
+		"other/sriov-dpdk": {SRIOV: true, Mode: DriverModeDPDK},
+		"default/calico":   {},
+	})
+	testCases := []struct {
+		networks string
+		selected bool
+		expected VFRequest
+	}{
+		{
+			networks: "calico",
+		},
+		{
+			networks: "calico,sriov-a,sriov-a@net2",
+			selected: true,
+			expected: VFRequest{Count: 2, Pool: "pool-a"},
+		},
+		{
+			networks: `[{"name": "sriov-dpdk", "namespace": "other"}, {"name": "missing"}]`,
+			selected: true,
+			expected: VFRequest{Count: 1, Mode: DriverModeDPDK},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Annotations: map[string]string{MultusNetworksAnnotation: tc.networks},
+			}}
+			req, selected, _ := selector(pod)
+			require.Equal(t, tc.selected, selected)
+			require.Equal(t, tc.expected, req)
+		})
+	}
+}
+
+func TestMultusSelectorMixedPools(t *testing.T) {
+	selector := MultusSelector(fakeNetworkAttachments{
+		"default/sriov-a": {SRIOV: true, Pool: "pool-a"},
+		"default/sriov-b": {SRIOV: true, Pool: "pool-b"},
+	})
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "default",
+		Annotations: map[string]string{MultusNetworksAnnotation: "sriov-a,sriov-b"},
+	}}
+	_, selected, err := selector(pod)
+	require.Error(t, err)
+	require.False(t, selected)
+}
+
+func TestNetworkAttachmentsInformer(t *testing.T) {
+	source := fake.NewFakeControllerSource()
+	informer := newNetworkAttachmentsInformerFromSource(source)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+	source.Add(makeNetworkAttachmentDefinition("default", "sriov-a", "pool-a", `{"type": "sriov"}`))
+	Eventually(t, func() error {
+		attachment, exists, err := informer.Get("default", "sriov-a")
+		if err != nil {
+			return err
+		}
+		expected := NetworkAttachment{SRIOV: true, Pool: "pool-a"}
+		if !exists || !reflect.DeepEqual(attachment, expected) {
+			return fmt.Errorf("Expected network attachment %v, got %v", expected, attachment)
+		}
+		if pools := informer.Pools(); !reflect.DeepEqual(pools, []v1.ResourceName{"pool-a"}) {
+			return fmt.Errorf("Expected pool-a, got %v", pools)
+		}
+		return nil
+	}, 100*time.Millisecond, 5*time.Millisecond)
+}
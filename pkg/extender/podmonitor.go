@@ -4,13 +4,15 @@ import (
 	"log"
 	"time"
 
-	"k8s.io/apimachinery/pkg/api/resource"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/pkg/api/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
+const podsResyncPeriod = 30 * time.Second
+
 // CreateMonitor creates pod informer.
 // This pod informer listens to pod changes and once node name is assigned by scheduler,
 // it removes global promise and adds allocated vf to a proper node
@@ -23,55 +25,93 @@ func (ext *Extender) CreateMonitor() cache.Controller {
 }
 
 func (ext *Extender) createMonitorFromSource(lw cache.ListerWatcher) cache.Controller {
-	_, controller := cache.NewInformer(
-		lw, &v1.Pod{}, 30*time.Second, cache.ResourceEventHandlerFuncs{
-			AddFunc:    ext.syncAllocated,
+	// list is an update too, informer may have no pods to resync
+	touchingLW := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			obj, err := lw.List(options)
+			if err == nil {
+				ext.health.podsUpdated()
+			}
+			return obj, err
+		},
+		WatchFunc: lw.Watch,
+	}
+	store, controller := cache.NewInformer(
+		touchingLW, &v1.Pod{}, podsResyncPeriod, cache.ResourceEventHandlerFuncs{
+			AddFunc:    ext.syncAdded,
 			UpdateFunc: ext.syncAllocatedFromUpdated,
 			DeleteFunc: ext.syncPurged,
 		},
 	)
+	ext.pods = store
+	ext.health.setPodsSynced(controller.HasSynced)
 	return controller
 }
 
 func (ext *Extender) syncPurged(obj interface{}) {
-	pod := obj.(*v1.Pod)
-	log.Printf("removing pod %s\n", pod.UID)
-	if !ext.selector(pod) {
-		log.Printf("pod %s skipped\n", pod.UID)
-		return
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			log.Printf("unexpected object removed %v\n", obj)
+			return
+		}
+		pod, ok = tombstone.Obj.(*v1.Pod)
+		if !ok {
+			log.Printf("unexpected object in tombstone %v\n", tombstone.Obj)
+			return
+		}
 	}
+	monitorEventsTotal.WithLabelValues("delete").Inc()
+	ext.health.podsUpdated()
+	log.Printf("removing pod %s\n", pod.UID)
 	ext.Lock()
 	defer ext.Unlock()
-	if _, exists := ext.allocatedVFs[pod.Spec.NodeName]; !exists {
-		ext.allocatedVFs[pod.Spec.NodeName] = resource.NewQuantity(0, resource.DecimalSI)
-	}
-	ext.allocatedVFs[pod.Spec.NodeName].Sub(*singleItem)
+	ext.release(pod.UID)
 	ext.promises.PurgePromise(pod.UID)
+	total := ext.allocated(pod.Spec.NodeName)[TotalVFsResource]
 	log.Printf(
 		"pod %s removed, total vfs for a node %s - %v\n",
-		pod.UID, pod.Spec.NodeName, ext.allocatedVFs[pod.Spec.NodeName])
+		pod.UID, pod.Spec.NodeName, &total)
 }
 
 func (ext *Extender) syncAllocated(obj interface{}) {
 	pod := obj.(*v1.Pod)
 	log.Printf("updating pod %s\n", pod.UID)
-	if !ext.selector(pod) {
+	req, selected := ext.allocationRequest(pod)
+	ext.Lock()
+	if !selected {
 		log.Printf("pod %s skipped\n", pod.UID)
+		ext.release(pod.UID)
+		ext.Unlock()
 		return
 	}
-	ext.Lock()
-	defer ext.Unlock()
-	if _, exists := ext.allocatedVFs[pod.Spec.NodeName]; !exists {
-		ext.allocatedVFs[pod.Spec.NodeName] = resource.NewQuantity(0, resource.DecimalSI)
+	terminated := podTerminated(pod)
+	var pf string
+	if terminated {
+		// kubelet releases resources of terminated pods
+		ext.release(pod.UID)
+	} else {
+		pf = ext.allocate(pod, req)
 	}
-	ext.allocatedVFs[pod.Spec.NodeName].Add(*singleItem)
 	ext.promises.PurgePromise(pod.UID)
+	ext.Unlock()
+	if !terminated {
+		ext.recordAllocation(pod, req, pf)
+	}
 	log.Printf("pod %s updated\n", pod.UID)
 }
 
+func (ext *Extender) syncAdded(obj interface{}) {
+	monitorEventsTotal.WithLabelValues("add").Inc()
+	ext.health.podsUpdated()
+	ext.syncAllocated(obj)
+}
+
 func (ext *Extender) syncAllocatedFromUpdated(old, new interface{}) {
-	// sync old pod only if it was updated
-	if !ext.selector(old.(*v1.Pod)) {
-		ext.syncAllocated(new)
-	}
+	monitorEventsTotal.WithLabelValues("update").Inc()
+	ext.health.podsUpdated()
+	ext.checkKubeletRejected(old.(*v1.Pod), new.(*v1.Pod))
+	// allocations are tracked per pod, so syncing an already allocated pod is a no-op
+	ext.syncAllocated(new)
 }
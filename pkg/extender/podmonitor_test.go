@@ -45,7 +45,8 @@ func TestPodMonitorFunctions(t *testing.T) {
 	Eventually(t, func() error {
 		ext.Lock()
 		defer ext.Unlock()
-		if ext.allocatedVFs["node1"].Cmp(single) != 0 {
+		allocated := ext.allocatedVFs["node1"][TotalVFsResource]
+		if allocated.Cmp(single) != 0 {
 			return fmt.Errorf("Expected one allocated VFs on node1")
 		}
 		return nil
@@ -56,7 +57,8 @@ func TestPodMonitorFunctions(t *testing.T) {
 	Eventually(t, func() error {
 		ext.Lock()
 		defer ext.Unlock()
-		if ext.allocatedVFs["node1"].Cmp(double) != 0 {
+		allocated := ext.allocatedVFs["node1"][TotalVFsResource]
+		if allocated.Cmp(double) != 0 {
 			return fmt.Errorf("Expected two allocated VFs on node1")
 		}
 		return nil
@@ -67,8 +69,9 @@ func TestPodMonitorFunctions(t *testing.T) {
 	Eventually(t, func() error {
 		ext.Lock()
 		defer ext.Unlock()
-		if !ext.allocatedVFs["node1"].IsZero() {
-			return fmt.Errorf("Expected no allocated VFs on node1, got %v", ext.allocatedVFs["node1"])
+		allocated := ext.allocatedVFs["node1"][TotalVFsResource]
+		if !allocated.IsZero() {
+			return fmt.Errorf("Expected no allocated VFs on node1, got %v", &allocated)
 		}
 		return nil
 	}, 10*time.Millisecond, 2*time.Millisecond)
@@ -0,0 +1,88 @@
+package extender
+
+import (
+	"fmt"
+	"log"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// ProcessPreemption keeps only nodes where preempting victims releases enough VFs for a pod.
+// Victims are returned unchanged, extender doesn't require preempting any other pods.
+func (ext *Extender) ProcessPreemption(args *ExtenderPreemptionArgs) (interface{}, error) {
+	if args.Pod == nil {
+		return nil, fmt.Errorf("pod is required")
+	}
+	log.Printf("Preemption called with pod %s/%s", args.Pod.Namespace, args.Pod.Name)
+	victims := args.NodeNameToMetaVictims
+	if victims == nil {
+		victims = make(map[string]*MetaVictims, len(args.NodeNameToVictims))
+		for nodeName, nodeVictims := range args.NodeNameToVictims {
+			meta := &MetaVictims{NumPDBViolations: nodeVictims.NumPDBViolations}
+			for _, pod := range nodeVictims.Pods {
+				meta.Pods = append(meta.Pods, &MetaPod{UID: string(pod.UID)})
+			}
+			victims[nodeName] = meta
+		}
+	}
+	result := &ExtenderPreemptionResult{NodeNameToMetaVictims: victims}
+	req, selected, err := ext.selector(args.Pod)
+	if err != nil {
+		return nil, err
+	}
+	if !selected {
+		return result, nil
+	}
+	result.NodeNameToMetaVictims = map[string]*MetaVictims{}
+	// nodes are fetched before taking the lock, so API latency doesn't block other verbs
+	nodes := make(map[string]*v1.Node, len(victims))
+	for nodeName := range victims {
+		node, err := ext.client.Core().Nodes().Get(nodeName, meta_v1.GetOptions{})
+		if err != nil {
+			log.Printf("Error getting node %s, it won't be preempted: %v", nodeName, err)
+			continue
+		}
+		nodes[nodeName] = node
+	}
+	ext.Lock()
+	defer ext.Unlock()
+	promised := ext.promises.PromisesCount()
+	for nodeName, node := range nodes {
+		nodeVictims := victims[nodeName]
+		allocated := ext.allocatedWithout(nodeName, nodeVictims)
+		if hasVFs, reason := checkNode(node, req, allocated, promised); !hasVFs || len(reason) != 0 {
+			log.Printf("Preempting victims on node %s doesn't help pod %s/%s: %s",
+				nodeName, args.Pod.Namespace, args.Pod.Name, reason)
+			continue
+		}
+		result.NodeNameToMetaVictims[nodeName] = nodeVictims
+	}
+	return result, nil
+}
+
+// allocatedWithout returns VFs allocated on a node as if victims were already removed,
+// must be called with extender lock held.
+func (ext *Extender) allocatedWithout(nodeName string, victims *MetaVictims) v1.ResourceList {
+	allocated := v1.ResourceList{}
+	for resName, quantity := range ext.allocated(nodeName) {
+		allocated[resName] = quantity.DeepCopy()
+	}
+	if victims == nil {
+		return allocated
+	}
+	for _, victim := range victims.Pods {
+		current, exists := ext.allocations[types.UID(victim.UID)]
+		if !exists || current.node != nodeName {
+			continue
+		}
+		for _, resName := range current.req.Resources() {
+			quantity := allocated[resName]
+			quantity.Sub(*resource.NewQuantity(current.req.Count, resource.DecimalSI))
+			allocated[resName] = quantity
+		}
+	}
+	return allocated
+}
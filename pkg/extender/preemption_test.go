@@ -0,0 +1,47 @@
+package extender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestProcessPreemption(t *testing.T) {
+	// both nodes have 2 VFs, node 0 is full and node 1 has one free VF
+	nodes := []v1.Node{makeNode(0, 2), makeNode(1, 2)}
+	ext := NewExtender(fake.NewSimpleClientset(&nodes[0], &nodes[1]))
+	for _, pod := range []*v1.Pod{makeVFPod("a", "0", 1), makeVFPod("b", "0", 1), makeVFPod("c", "1", 1)} {
+		req, _, _ := ext.selector(pod)
+		ext.allocate(pod, req)
+	}
+	preemptor := makeVFPod("preemptor", "", 2)
+
+	result, err := ext.ProcessPreemption(&ExtenderPreemptionArgs{
+		Pod: preemptor,
+		NodeNameToMetaVictims: map[string]*MetaVictims{
+			"0": {Pods: []*MetaPod{{UID: "a"}}},
+			"1": {Pods: []*MetaPod{{UID: "c"}}},
+			"2": {Pods: []*MetaPod{{UID: "d"}}},
+		},
+	})
+	require.NoError(t, err)
+	victims := result.(*ExtenderPreemptionResult).NodeNameToMetaVictims
+	require.Len(t, victims, 1)
+	require.Contains(t, victims, "1")
+
+	result, err = ext.ProcessPreemption(&ExtenderPreemptionArgs{
+		Pod: preemptor,
+		NodeNameToVictims: map[string]*Victims{
+			"0": {Pods: []*v1.Pod{makeVFPod("a", "0", 1), makeVFPod("b", "0", 1)}, NumPDBViolations: 1},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]*MetaVictims{
+		"0": {Pods: []*MetaPod{{UID: "a"}, {UID: "b"}}, NumPDBViolations: 1},
+	}, result.(*ExtenderPreemptionResult).NodeNameToMetaVictims)
+	// preempting pods doesn't change allocations until pods are removed
+	allocated := ext.allocatedVFs["0"][TotalVFsResource]
+	require.Equal(t, int64(2), allocated.Value())
+}
@@ -3,6 +3,7 @@ package extender
 import (
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
@@ -12,34 +13,58 @@ import (
 
 const (
 	defaultPromisesCleanerInterval = 5 * time.Second
+	// promiseTimeout is how long VFs stay promised to a pod that isn't bound to a node.
+	promiseTimeout = 10 * time.Second
 )
 
 type PromisesInterface interface {
 	PurgePromise(types.UID)
-	MakePromise(types.UID)
+	MakePromise(types.UID, int64)
 	PromisesCount() *resource.Quantity
 	Subscribe(chan struct{})
 	RunPromisesCleaner(time.Duration, <-chan struct{})
+	// LastCleaned returns time of the last tick of the promises cleaner, zero if it never ran.
+	LastCleaned() time.Time
+	// List returns promises sorted by pod UID.
+	List() []PromiseStatus
+	// OnExpired registers a function called with UID of a pod once its promise expires.
+	OnExpired(func(types.UID))
 }
 
 func NewPromises() PromisesInterface {
 	return &Promises{
-		promises:    map[types.UID]time.Time{},
+		promises:    map[types.UID]promise{},
 		subscribers: make([]chan struct{}, 0, 1),
 	}
 }
 
+// promise is a number of VFs reserved for a pod at a particular time.
+type promise struct {
+	made  time.Time
+	count int64
+}
+
+// PromiseStatus is a number of VFs promised to a pod.
+type PromiseStatus struct {
+	UID   types.UID
+	Count int64
+	Made  time.Time
+}
+
 type Promises struct {
 	sync.Mutex
-	promises    map[types.UID]time.Time
+	promises    map[types.UID]promise
 	subscribers []chan struct{}
+	cleaned     time.Time
+	expired     func(types.UID)
 }
 
-func (p *Promises) MakePromise(uid types.UID) {
+func (p *Promises) MakePromise(uid types.UID, count int64) {
 	p.Lock()
 	defer p.Unlock()
-	log.Printf("promise made for %s\n", uid)
-	p.promises[uid] = time.Now()
+	log.Printf("promise of %d vfs made for %s\n", count, uid)
+	p.promises[uid] = promise{made: time.Now(), count: count}
+	promisesOutstanding.Set(float64(len(p.promises)))
 }
 
 func (p *Promises) PurgePromise(uid types.UID) {
@@ -53,6 +78,7 @@ func (p *Promises) purgePromise(uid types.UID) {
 		return
 	}
 	delete(p.promises, uid)
+	promisesOutstanding.Set(float64(len(p.promises)))
 	for _, s := range p.subscribers {
 		close(s)
 	}
@@ -62,8 +88,23 @@ func (p *Promises) purgePromise(uid types.UID) {
 func (p *Promises) PromisesCount() *resource.Quantity {
 	p.Lock()
 	defer p.Unlock()
-	log.Printf("promises count %d\n", len(p.promises))
-	return resource.NewQuantity(int64(len(p.promises)), resource.DecimalSI)
+	var count int64
+	for _, promise := range p.promises {
+		count += promise.count
+	}
+	log.Printf("promises count %d for %d vfs\n", len(p.promises), count)
+	return resource.NewQuantity(count, resource.DecimalSI)
+}
+
+func (p *Promises) List() []PromiseStatus {
+	p.Lock()
+	defer p.Unlock()
+	statuses := make([]PromiseStatus, 0, len(p.promises))
+	for uid, promise := range p.promises {
+		statuses = append(statuses, PromiseStatus{UID: uid, Count: promise.count, Made: promise.made})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].UID < statuses[j].UID })
+	return statuses
 }
 
 func (p *Promises) Subscribe(waitChan chan struct{}) {
@@ -74,23 +115,53 @@ func (p *Promises) Subscribe(waitChan chan struct{}) {
 
 func (p *Promises) RunPromisesCleaner(interval time.Duration, stopCh <-chan struct{}) {
 	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	p.Lock()
+	p.cleaned = time.Now()
+	p.Unlock()
 	for {
 		select {
-		case <-ticker.C:
+		case now := <-ticker.C:
 			fmt.Println("Purging promises.")
-			p.purgePromises(time.Now())
+			expired := p.purgePromises(now)
+			p.Lock()
+			onExpired := p.expired
+			p.Unlock()
+			for _, uid := range expired {
+				if onExpired != nil {
+					onExpired(uid)
+				}
+			}
 		case <-stopCh:
 			return
 		}
 	}
 }
 
-func (p *Promises) purgePromises(fromTime time.Time) {
+func (p *Promises) LastCleaned() time.Time {
+	p.Lock()
+	defer p.Unlock()
+	return p.cleaned
+}
+
+func (p *Promises) OnExpired(f func(types.UID)) {
+	p.Lock()
+	defer p.Unlock()
+	p.expired = f
+}
+
+// purgePromises removes expired promises and returns UIDs of their pods.
+func (p *Promises) purgePromises(fromTime time.Time) []types.UID {
 	p.Lock()
 	defer p.Unlock()
+	p.cleaned = fromTime
+	var expired []types.UID
 	for podUID, promise := range p.promises {
-		if promise.Sub(fromTime).Seconds() >= (10 * time.Second).Seconds() {
+		if fromTime.Sub(promise.made) >= promiseTimeout {
 			p.purgePromise(podUID)
+			promiseExpirationsTotal.Inc()
+			expired = append(expired, podUID)
 		}
 	}
+	return expired
 }
@@ -9,12 +9,12 @@ import (
 
 func TestPromisesCleaner(t *testing.T) {
 	p := &Promises{
-		promises:    map[types.UID]time.Time{},
+		promises:    map[types.UID]promise{},
 		subscribers: make([]chan struct{}, 0, 1),
 	}
-	invalidPromise := time.Now().Add(11 * time.Second)
-	validPromise := time.Now()
-	p.promises = map[types.UID]time.Time{
+	invalidPromise := promise{made: time.Now().Add(-11 * time.Second), count: 1}
+	validPromise := promise{made: time.Now(), count: 1}
+	p.promises = map[types.UID]promise{
 		types.UID("1"): invalidPromise,
 		types.UID("2"): invalidPromise,
 		types.UID("3"): validPromise,
@@ -24,3 +24,16 @@ func TestPromisesCleaner(t *testing.T) {
 		t.Errorf("Only one promise is valid: %v", p.promises)
 	}
 }
+
+func TestPromisesCount(t *testing.T) {
+	p := NewPromises()
+	p.MakePromise(types.UID("1"), 1)
+	p.MakePromise(types.UID("2"), 3)
+	if count := p.PromisesCount(); count.Value() != 4 {
+		t.Errorf("Expected 4 promised vfs, got %v", count)
+	}
+	p.PurgePromise(types.UID("2"))
+	if count := p.PromisesCount(); count.Value() != 1 {
+		t.Errorf("Expected 1 promised vf, got %v", count)
+	}
+}
@@ -0,0 +1,142 @@
+package extender
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// APIVersion is a revision of the scheduler extender wire protocol.
+type APIVersion string
+
+const (
+	// APIVersion17 is spoken by schedulers of kubernetes 1.7 to 1.10, its fields have no JSON tags.
+	APIVersion17 APIVersion = "v1.7"
+	// APIVersion111 adds JSON tags, preemption and binding, kubernetes 1.11 to 1.19.
+	APIVersion111 APIVersion = "v1.11"
+	// APIVersion120 adds FailedAndUnresolvableNodes to filter result, kubernetes 1.20 and newer.
+	APIVersion120 APIVersion = "v1.20"
+	// DefaultAPIVersion is used if configuration doesn't set any.
+	DefaultAPIVersion = APIVersion17
+)
+
+// Protocol converts results of the extender to a wire format of a protocol revision.
+// Requests of every revision are decoded into extender types directly, because JSON
+// field names differ only in case and older revisions miss some fields.
+type Protocol struct {
+	Version APIVersion
+	// Preemption is true if revision has preempt verb.
+	Preemption bool
+	// Binding is true if revision has bind verb.
+	Binding bool
+	encode  func(obj interface{}) interface{}
+}
+
+var protocols = map[APIVersion]*Protocol{
+	APIVersion17:  {Version: APIVersion17, encode: encodeV17},
+	APIVersion111: {Version: APIVersion111, Preemption: true, Binding: true, encode: encodeV111},
+	APIVersion120: {Version: APIVersion120, Preemption: true, Binding: true, encode: encodeV120},
+}
+
+// ProtocolFor returns protocol of a revision, DefaultAPIVersion if version is empty.
+func ProtocolFor(version APIVersion) (*Protocol, error) {
+	if version == "" {
+		version = DefaultAPIVersion
+	}
+	protocol, exists := protocols[version]
+	if !exists {
+		return nil, fmt.Errorf("unknown api version %q", version)
+	}
+	return protocol, nil
+}
+
+func (p *Protocol) encodeResult(result interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return result, err
+	}
+	return p.encode(result), nil
+}
+
+type filterResultV17 struct {
+	Nodes       *v1.NodeList
+	NodeNames   *[]string
+	FailedNodes FailedNodesMap
+	Error       string
+}
+
+func encodeV17(obj interface{}) interface{} {
+	if result, ok := obj.(*ExtenderFilterResult); ok && result != nil {
+		return &filterResultV17{
+			Nodes:       result.Nodes,
+			NodeNames:   result.NodeNames,
+			FailedNodes: result.FailedNodes,
+			Error:       result.Error,
+		}
+	}
+	return obj
+}
+
+type extenderArgsV1 struct {
+	Pod       v1.Pod       `json:"pod"`
+	Nodes     *v1.NodeList `json:"nodes,omitempty"`
+	NodeNames *[]string    `json:"nodenames,omitempty"`
+}
+
+type filterResultV111 struct {
+	Nodes       *v1.NodeList   `json:"nodes,omitempty"`
+	NodeNames   *[]string      `json:"nodenames,omitempty"`
+	FailedNodes FailedNodesMap `json:"failedNodes,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+type filterResultV120 struct {
+	Nodes                      *v1.NodeList   `json:"nodes,omitempty"`
+	NodeNames                  *[]string      `json:"nodenames,omitempty"`
+	FailedNodes                FailedNodesMap `json:"failedNodes,omitempty"`
+	FailedAndUnresolvableNodes FailedNodesMap `json:"failedAndUnresolvableNodes,omitempty"`
+	Error                      string         `json:"error,omitempty"`
+}
+
+type hostPriorityV1 struct {
+	Host  string `json:"host"`
+	Score int64  `json:"score"`
+}
+
+// encodeV1 encodes types shared by v1.11 and newer revisions.
+func encodeV1(obj interface{}) interface{} {
+	switch obj := obj.(type) {
+	case *ExtenderArgs:
+		return (*extenderArgsV1)(obj)
+	case *HostPriorityList:
+		if obj == nil {
+			return obj
+		}
+		return encodeV1(*obj)
+	case HostPriorityList:
+		priorities := make([]hostPriorityV1, 0, len(obj))
+		for _, priority := range obj {
+			priorities = append(priorities, hostPriorityV1(priority))
+		}
+		return priorities
+	}
+	return obj
+}
+
+func encodeV111(obj interface{}) interface{} {
+	if result, ok := obj.(*ExtenderFilterResult); ok && result != nil {
+		return &filterResultV111{
+			Nodes:       result.Nodes,
+			NodeNames:   result.NodeNames,
+			FailedNodes: result.FailedNodes,
+			Error:       result.Error,
+		}
+	}
+	return encodeV1(obj)
+}
+
+func encodeV120(obj interface{}) interface{} {
+	if result, ok := obj.(*ExtenderFilterResult); ok && result != nil {
+		return (*filterResultV120)(result)
+	}
+	return encodeV1(obj)
+}
@@ -0,0 +1,62 @@
+package extender
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolRoundTrip(t *testing.T) {
+	fixtures := map[string]func() interface{}{
+		"filter_args.json":       func() interface{} { return &ExtenderArgs{} },
+		"filter_result.json":     func() interface{} { return &ExtenderFilterResult{} },
+		"prioritize_result.json": func() interface{} { return &HostPriorityList{} },
+		"preemption_args.json":   func() interface{} { return &ExtenderPreemptionArgs{} },
+		"preemption_result.json": func() interface{} { return &ExtenderPreemptionResult{} },
+		"binding_args.json":      func() interface{} { return &ExtenderBindingArgs{} },
+		"binding_result.json":    func() interface{} { return &ExtenderBindingResult{} },
+	}
+	for version, protocol := range protocols {
+		for name, newObj := range fixtures {
+			path := filepath.Join("testdata", "api", string(version), name)
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				require.False(t, protocol.Preemption && protocol.Binding, "missing fixture %s", path)
+				continue
+			}
+			t.Run(path, func(t *testing.T) {
+				obj := newObj()
+				require.NoError(t, json.Unmarshal(data, obj))
+				encoded, err := json.Marshal(protocol.encode(obj))
+				require.NoError(t, err)
+				require.JSONEq(t, string(data), string(encoded))
+			})
+		}
+	}
+}
+
+func TestProtocolFilterResult(t *testing.T) {
+	result := &ExtenderFilterResult{
+		FailedNodes:                FailedNodesMap{"node-1": "not enough"},
+		FailedAndUnresolvableNodes: FailedNodesMap{"node-2": "no VFs"},
+	}
+	for i, tc := range []struct {
+		version  APIVersion
+		expected string
+	}{
+		{"", `{"Nodes": null, "NodeNames": null, "FailedNodes": {"node-1": "not enough"}, "Error": ""}`},
+		{APIVersion111, `{"failedNodes": {"node-1": "not enough"}}`},
+		{APIVersion120, `{"failedNodes": {"node-1": "not enough"}, "failedAndUnresolvableNodes": {"node-2": "no VFs"}}`},
+	} {
+		protocol, err := ProtocolFor(tc.version)
+		require.NoError(t, err)
+		encoded, err := json.Marshal(protocol.encode(result))
+		require.NoError(t, err)
+		require.JSONEq(t, tc.expected, string(encoded), "case %d", i)
+	}
+	_, err := ProtocolFor("v0.1")
+	require.Error(t, err)
+}
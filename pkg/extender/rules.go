@@ -0,0 +1,183 @@
+package extender
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const defaultSeparator = ","
+
+// SelectorRule requests VFs for pods that match all of its conditions.
+type SelectorRule struct {
+	// Name of the rule used in logs and errors.
+	Name string `json:"name"`
+	// Annotation with a list of networks, e.g. networks.
+	Annotation string `json:"annotation,omitempty"`
+	// Separator of networks in the annotation, comma by default.
+	Separator string `json:"separator,omitempty"`
+	// Token is a regular expression a network in the annotation has to match completely.
+	// Every matching network requests Count VFs. If empty any network matches.
+	Token string `json:"token,omitempty"`
+	// LabelSelector selects pods by their labels.
+	LabelSelector *meta_v1.LabelSelector `json:"labelSelector,omitempty"`
+	// NamespaceSelector selects pods by labels of their namespace.
+	NamespaceSelector *meta_v1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Count of VFs requested by every matching network, or by a pod if rule has no annotation.
+	// Defaults to 1.
+	Count int64 `json:"count,omitempty"`
+	// Pool is a node resource VFs are allocated from.
+	Pool v1.ResourceName `json:"pool,omitempty"`
+	// Mode of the driver VFs have to be bound to.
+	Mode DriverMode `json:"mode,omitempty"`
+}
+
+// compiledRule is a validated SelectorRule ready for matching pods.
+type compiledRule struct {
+	*SelectorRule
+	separator         string
+	token             *regexp.Regexp
+	labelSelector     labels.Selector
+	namespaceSelector labels.Selector
+}
+
+func (r *SelectorRule) compile() (*compiledRule, error) {
+	if r.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if r.Annotation == "" && r.LabelSelector == nil && r.NamespaceSelector == nil {
+		return nil, fmt.Errorf("at least one of annotation, labelSelector or namespaceSelector is required")
+	}
+	if r.Annotation == "" && (r.Token != "" || r.Separator != "") {
+		return nil, fmt.Errorf("token and separator can be used only with annotation")
+	}
+	if r.Count < 0 {
+		return nil, fmt.Errorf("count can't be negative")
+	}
+	switch r.Mode {
+	case DriverModeAny, DriverModeNetdev, DriverModeDPDK:
+	default:
+		return nil, fmt.Errorf("unknown driver mode %q", r.Mode)
+	}
+	compiled := &compiledRule{SelectorRule: r, separator: r.Separator}
+	if compiled.separator == "" {
+		compiled.separator = defaultSeparator
+	}
+	if r.Token != "" {
+		token, err := regexp.Compile("^(?:" + r.Token + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid token: %v", err)
+		}
+		compiled.token = token
+	}
+	var err error
+	if r.LabelSelector != nil {
+		if compiled.labelSelector, err = meta_v1.LabelSelectorAsSelector(r.LabelSelector); err != nil {
+			return nil, fmt.Errorf("invalid labelSelector: %v", err)
+		}
+	}
+	if r.NamespaceSelector != nil {
+		if compiled.namespaceSelector, err = meta_v1.LabelSelectorAsSelector(r.NamespaceSelector); err != nil {
+			return nil, fmt.Errorf("invalid namespaceSelector: %v", err)
+		}
+	}
+	return compiled, nil
+}
+
+// count returns number of VFs rule requests for a pod, zero if pod doesn't match.
+func (r *compiledRule) count(pod *v1.Pod, namespaceLabels NamespaceLabels) int64 {
+	perMatch := r.Count
+	if perMatch == 0 {
+		perMatch = 1
+	}
+	if r.labelSelector != nil && !r.labelSelector.Matches(labels.Set(pod.Labels)) {
+		return 0
+	}
+	if r.namespaceSelector != nil {
+		if namespaceLabels == nil {
+			return 0
+		}
+		nsLabels, err := namespaceLabels(pod.Namespace)
+		if err != nil {
+			log.Printf("Rule %s: error getting labels of namespace %s: %v", r.Name, pod.Namespace, err)
+			return 0
+		}
+		if !r.namespaceSelector.Matches(labels.Set(nsLabels)) {
+			return 0
+		}
+	}
+	if r.Annotation == "" {
+		return perMatch
+	}
+	networks, exists := pod.Annotations[r.Annotation]
+	if !exists {
+		return 0
+	}
+	var matched int64
+	for _, net := range strings.Split(networks, r.separator) {
+		// empty annotation or empty items, e.g. of a trailing separator, request nothing
+		net = strings.TrimSpace(net)
+		if net == "" {
+			continue
+		}
+		if r.token == nil || r.token.MatchString(net) {
+			matched++
+		}
+	}
+	return matched * perMatch
+}
+
+// NamespaceLabels returns labels of a namespace.
+type NamespaceLabels func(namespace string) (map[string]string, error)
+
+// RulesSelector creates selector from configured rules, the first matching rule wins.
+// Rules are expected to be validated with Config.Validate.
+func RulesSelector(rules []SelectorRule, namespaceLabels NamespaceLabels) (Selector, error) {
+	compiled := make([]*compiledRule, 0, len(rules))
+	for i := range rules {
+		rule, err := rules[i].compile()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %v", i, rules[i].Name, err)
+		}
+		compiled = append(compiled, rule)
+	}
+	return func(pod *v1.Pod) (VFRequest, bool, error) {
+		for _, rule := range compiled {
+			if count := rule.count(pod, namespaceLabels); count > 0 {
+				return VFRequest{Mode: rule.Mode, Count: count, Pool: rule.Pool}, true, nil
+			}
+		}
+		return VFRequest{}, false, nil
+	}, nil
+}
+
+// NewNamespaceInformer creates informer that caches namespaces for rules with namespaceSelector.
+func NewNamespaceInformer(client kubernetes.Interface) (NamespaceLabels, cache.Controller) {
+	lw := cache.NewListWatchFromClient(
+		client.Core().RESTClient(), "namespaces", meta_v1.NamespaceAll, fields.Everything())
+	return namespaceInformerFromSource(lw)
+}
+
+func namespaceInformerFromSource(lw cache.ListerWatcher) (NamespaceLabels, cache.Controller) {
+	store, controller := cache.NewInformer(
+		lw, &v1.Namespace{}, 30*time.Second, cache.ResourceEventHandlerFuncs{})
+	return func(namespace string) (map[string]string, error) {
+		obj, exists, err := store.GetByKey(namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("namespace %s not found", namespace)
+		}
+		return obj.(*v1.Namespace).Labels, nil
+	}, controller
+}
@@ -0,0 +1,175 @@
+package extender
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestRulesSelector(t *testing.T) {
+	rules := []SelectorRule{
+		{
+			Name:       "dpdk",
+			Annotation: "cni.example.com/networks",
+			Separator:  ";",
+			Token:      "sriov-dpdk(-[0-9]+)?",
+			Mode:       DriverModeDPDK,
+			Pool:       "pool-dpdk",
+		},
+		{
+			Name:       "networks",
+			Annotation: "networks",
+			Token:      "sriov",
+		},
+		{
+			Name:              "cnf",
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cnf"}},
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"sriov": "enabled"}},
+			Count:             2,
+		},
+		{
+			Name:       "interfaces",
+			Annotation: "example.com/interfaces",
+		},
+	}
+	namespaces := map[string]map[string]string{
+		"telco":   {"sriov": "enabled"},
+		"default": {},
+	}
+	namespaceLabels := func(namespace string) (map[string]string, error) {
+		if nsLabels, exists := namespaces[namespace]; exists {
+			return nsLabels, nil
+		}
+		return nil, fmt.Errorf("namespace %s not found", namespace)
+	}
+	selector, err := RulesSelector(rules, namespaceLabels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		namespace   string
+		labels      map[string]string
+		annotations map[string]string
+		selected    bool
+		expected    VFRequest
+	}{
+		{
+			annotations: map[string]string{"networks": "sriov,contrail"},
+			selected:    true,
+			expected:    VFRequest{Count: 1},
+		},
+		{
+			annotations: map[string]string{"networks": "sriov,sriov"},
+			selected:    true,
+			expected:    VFRequest{Count: 2},
+		},
+		{
+			annotations: map[string]string{"networks": "sriov-2,contrail"},
+		},
+		{
+			annotations: map[string]string{"cni.example.com/networks": "sriov-dpdk-1;sriov-dpdk-2;calico"},
+			selected:    true,
+			expected:    VFRequest{Mode: DriverModeDPDK, Count: 2, Pool: "pool-dpdk"},
+		},
+		{
+			annotations: map[string]string{"cni.example.com/networks": "sriov-dpdk,calico"},
+		},
+		{
+			namespace: "telco",
+			labels:    map[string]string{"app": "cnf"},
+			selected:  true,
+			expected:  VFRequest{Count: 2},
+		},
+		{
+			namespace: "default",
+			labels:    map[string]string{"app": "cnf"},
+		},
+		{
+			namespace: "missing",
+			labels:    map[string]string{"app": "cnf"},
+		},
+		{
+			namespace: "telco",
+			labels:    map[string]string{"app": "web"},
+		},
+		{
+			annotations: map[string]string{"example.com/interfaces": ""},
+		},
+		{
+			annotations: map[string]string{"example.com/interfaces": "net1, ,net2,"},
+			selected:    true,
+			expected:    VFRequest{Count: 2},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   tc.namespace,
+				Labels:      tc.labels,
+				Annotations: tc.annotations,
+			}}
+			req, selected, _ := selector(pod)
+			if selected != tc.selected {
+				t.Fatalf("Expected selected %v, received %v for pod %v", tc.selected, selected, pod.ObjectMeta)
+			}
+			if req != tc.expected {
+				t.Errorf("Expected request %v is different from received %v", tc.expected, req)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		rules []SelectorRule
+		valid bool
+	}{
+		{
+			rules: []SelectorRule{{Name: "networks", Annotation: "networks", Token: "sriov"}},
+			valid: true,
+		},
+		{
+			rules: []SelectorRule{{Annotation: "networks"}},
+		},
+		{
+			rules: []SelectorRule{{Name: "empty"}},
+		},
+		{
+			rules: []SelectorRule{{Name: "token", Token: "sriov"}},
+		},
+		{
+			rules: []SelectorRule{{Name: "regexp", Annotation: "networks", Token: "sriov("}},
+		},
+		{
+			rules: []SelectorRule{{Name: "count", Annotation: "networks", Count: -1}},
+		},
+		{
+			rules: []SelectorRule{{Name: "mode", Annotation: "networks", Mode: "kernel"}},
+		},
+		{
+			rules: []SelectorRule{{
+				Name: "selector",
+				LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "app", Operator: "Unknown"},
+				}},
+			}},
+		},
+		{
+			rules: []SelectorRule{
+				{Name: "networks", Annotation: "networks"},
+				{Name: "networks", Annotation: "other"},
+			},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			config := &Config{Rules: tc.rules}
+			if err := config.Validate(); (err == nil) != tc.valid {
+				t.Errorf("Expected valid %v for rules %v, received error %v", tc.valid, tc.rules, err)
+			}
+		})
+	}
+}
@@ -1,22 +1,162 @@
 package extender
 
 import (
+	"fmt"
+	"reflect"
 	"strings"
 
 	"k8s.io/client-go/pkg/api/v1"
 )
 
-type Selector func(pod *v1.Pod) bool
+const (
+	// DriverModeAnnotation selects a driver mode requested VFs have to be bound to.
+	DriverModeAnnotation = "vfdriver"
+)
+
+// DriverMode is a type of driver virtual function is bound to.
+type DriverMode string
+
+const (
+	// DriverModeAny means that pod can use VF bound to any driver.
+	DriverModeAny DriverMode = ""
+	// DriverModeNetdev is a VF bound to a kernel driver and exposed as a network device.
+	DriverModeNetdev DriverMode = "netdev"
+	// DriverModeDPDK is a VF bound to a userspace driver, such as vfio-pci.
+	DriverModeDPDK DriverMode = "dpdk"
+	// driverModeAnyValue is an explicit value of DriverModeAnnotation for DriverModeAny.
+	driverModeAnyValue = "any"
+)
+
+// ParseDriverMode parses a value of DriverModeAnnotation, any and empty value mean DriverModeAny.
+func ParseDriverMode(value string) (DriverMode, error) {
+	switch mode := DriverMode(value); mode {
+	case DriverModeAny, DriverModeNetdev, DriverModeDPDK:
+		return mode, nil
+	case driverModeAnyValue:
+		return DriverModeAny, nil
+	}
+	return DriverModeAny, fmt.Errorf("unknown driver mode %q, expected %s, %s or %s",
+		value, DriverModeNetdev, DriverModeDPDK, driverModeAnyValue)
+}
+
+// Resource returns node resource with a number of VFs in this mode,
+// e.g. NetdevVFsResource for DriverModeNetdev.
+func (m DriverMode) Resource() v1.ResourceName {
+	if m == DriverModeAny {
+		return TotalVFsResource
+	}
+	return v1.ResourceName(string(m) + "vfs")
+}
+
+// VFRequest describes virtual functions required by a pod.
+type VFRequest struct {
+	// Mode of the driver VF has to be bound to.
+	Mode DriverMode
+	// Count of VFs pod requires.
+	Count int64
+	// Pool is a node resource VFs are allocated from, in addition to totalvfs.
+	Pool v1.ResourceName
+}
+
+// Resources returns all node resources a request has to be accounted against.
+func (r VFRequest) Resources() []v1.ResourceName {
+	resources := []v1.ResourceName{TotalVFsResource}
+	if r.Mode != DriverModeAny {
+		resources = append(resources, r.Mode.Resource())
+	}
+	for _, resName := range resources {
+		if resName == r.Pool {
+			return resources
+		}
+	}
+	if r.Pool != "" {
+		resources = append(resources, r.Pool)
+	}
+	return resources
+}
+
+// sameRequest returns true if requests take the same number of VFs of the same mode from the
+// same resources, e.g. a request with an empty pool and one recorded with its default pool.
+func sameRequest(a, b VFRequest) bool {
+	return a.Mode == b.Mode && a.Count == b.Count && reflect.DeepEqual(a.Resources(), b.Resources())
+}
+
+// Selector decides if pod requires virtual functions and which ones. Error is returned
+// if pod requests VFs but the request is invalid, e.g. has an unknown driver mode.
+type Selector func(pod *v1.Pod) (VFRequest, bool, error)
 
 // NetworkSelector decides if pod requires virtual function.
-func NetworkSelector(pod *v1.Pod) bool {
+// Driver mode of the VF is read from the DriverModeAnnotation.
+func NetworkSelector(pod *v1.Pod) (VFRequest, bool, error) {
 	if networksString, exists := pod.Annotations["networks"]; exists {
 		networks := strings.Split(networksString, ",")
 		for _, net := range networks {
 			if net == "sriov" {
-				return true
+				mode, err := ParseDriverMode(pod.Annotations[DriverModeAnnotation])
+				if err != nil {
+					return VFRequest{}, false, fmt.Errorf("invalid %s annotation of pod %s/%s: %v",
+						DriverModeAnnotation, pod.Namespace, pod.Name, err)
+				}
+				return VFRequest{Mode: mode, Count: 1}, true, nil
+			}
+		}
+	}
+	return VFRequest{}, false, nil
+}
+
+// ResourceSelector derives VF demand from container resource limits and requests.
+// VFs bound to a particular driver are requested with the resource of that mode,
+// e.g. dpdkvfs, any VFs are requested with totalvfs. A request is a single mode,
+// so pods demanding both netdev and dpdk VFs are rejected.
+func ResourceSelector(pod *v1.Pod) (VFRequest, bool, error) {
+	if podDemand(pod, DriverModeNetdev.Resource()) > 0 && podDemand(pod, DriverModeDPDK.Resource()) > 0 {
+		return VFRequest{}, false, fmt.Errorf("pod %s/%s requests both %s and %s, only one VF driver mode is supported",
+			pod.Namespace, pod.Name, DriverModeNetdev.Resource(), DriverModeDPDK.Resource())
+	}
+	for _, mode := range []DriverMode{DriverModeDPDK, DriverModeNetdev, DriverModeAny} {
+		if count := podDemand(pod, mode.Resource()); count > 0 {
+			return VFRequest{Mode: mode, Count: count}, true, nil
+		}
+	}
+	return VFRequest{}, false, nil
+}
+
+// FallbackSelector returns request of the first selector that selects a pod or fails.
+func FallbackSelector(selectors ...Selector) Selector {
+	return func(pod *v1.Pod) (VFRequest, bool, error) {
+		for _, selector := range selectors {
+			if req, selected, err := selector(pod); selected || err != nil {
+				return req, selected, err
 			}
 		}
+		return VFRequest{}, false, nil
+	}
+}
+
+// podDemand computes resource demand the same way kubelet does it. Init containers
+// run one by one, so pod requires the maximum of the largest init container demand
+// and the sum of regular containers demands.
+func podDemand(pod *v1.Pod, res v1.ResourceName) int64 {
+	var demand int64
+	for i := range pod.Spec.Containers {
+		demand += containerDemand(&pod.Spec.Containers[i], res)
+	}
+	for i := range pod.Spec.InitContainers {
+		if initDemand := containerDemand(&pod.Spec.InitContainers[i], res); initDemand > demand {
+			demand = initDemand
+		}
+	}
+	return demand
+}
+
+// containerDemand returns container request or limit for the resource, whichever is bigger.
+func containerDemand(container *v1.Container, res v1.ResourceName) int64 {
+	var demand int64
+	if limit, exists := container.Resources.Limits[res]; exists {
+		demand = limit.Value()
+	}
+	if request, exists := container.Resources.Requests[res]; exists && request.Value() > demand {
+		demand = request.Value()
 	}
-	return false
+	return demand
 }
@@ -1,9 +1,11 @@
 package extender
 
 import (
+	"reflect"
 	"strconv"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/pkg/api/v1"
 )
 
@@ -37,11 +39,166 @@ func TestNetworkSelector(t *testing.T) {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			pod := &v1.Pod{}
 			pod.SetAnnotations(map[string]string{"networks": tc.networks})
-			if result := NetworkSelector(pod); result != tc.expected {
+			if _, result, _ := NetworkSelector(pod); result != tc.expected {
 				t.Errorf("Expected result %v is different from received %v for networks %s",
 					tc.expected, result, tc.networks)
 			}
 		})
 	}
+}
+
+func TestNetworkSelectorDriverMode(t *testing.T) {
+	testCases := []struct {
+		annotations map[string]string
+		resources   []v1.ResourceName
+	}{
+		{
+			annotations: map[string]string{"networks": "sriov"},
+			resources:   []v1.ResourceName{TotalVFsResource},
+		},
+		{
+			annotations: map[string]string{"networks": "sriov", DriverModeAnnotation: "netdev"},
+			resources:   []v1.ResourceName{TotalVFsResource, NetdevVFsResource},
+		},
+		{
+			annotations: map[string]string{"networks": "sriov", DriverModeAnnotation: "dpdk"},
+			resources:   []v1.ResourceName{TotalVFsResource, DPDKVFsResource},
+		},
+		{
+			annotations: map[string]string{"networks": "sriov", DriverModeAnnotation: "any"},
+			resources:   []v1.ResourceName{TotalVFsResource},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			pod := &v1.Pod{}
+			pod.SetAnnotations(tc.annotations)
+			req, selected, err := NetworkSelector(pod)
+			if err != nil {
+				t.Fatalf("Unexpected error for pod with annotations %v: %v", tc.annotations, err)
+			}
+			if !selected {
+				t.Fatalf("Pod with annotations %v is expected to be selected", tc.annotations)
+			}
+			if resources := req.Resources(); !reflect.DeepEqual(resources, tc.resources) {
+				t.Errorf("Expected resources %v are different from received %v", tc.resources, resources)
+			}
+		})
+	}
+}
+
+func TestNetworkSelectorInvalidDriverMode(t *testing.T) {
+	pod := &v1.Pod{}
+	pod.SetAnnotations(map[string]string{"networks": "sriov", DriverModeAnnotation: "vfio"})
+	if _, _, err := NetworkSelector(pod); err == nil {
+		t.Errorf("Expected error for invalid %s annotation", DriverModeAnnotation)
+	}
+}
+
+func TestVFRequestResources(t *testing.T) {
+	testCases := []struct {
+		req       VFRequest
+		resources []v1.ResourceName
+	}{
+		{
+			req:       VFRequest{Pool: TotalVFsResource},
+			resources: []v1.ResourceName{TotalVFsResource},
+		},
+		{
+			req:       VFRequest{Mode: DriverModeDPDK, Pool: "pool-a"},
+			resources: []v1.ResourceName{TotalVFsResource, DPDKVFsResource, "pool-a"},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if resources := tc.req.Resources(); !reflect.DeepEqual(resources, tc.resources) {
+				t.Errorf("Expected resources %v are different from received %v", tc.resources, resources)
+			}
+		})
+	}
+}
+
+func TestResourceSelector(t *testing.T) {
+	vfs := func(res v1.ResourceName, count int64) v1.ResourceList {
+		return v1.ResourceList{res: *resource.NewQuantity(count, resource.DecimalSI)}
+	}
+	testCases := []struct {
+		containers     []v1.Container
+		initContainers []v1.Container
+		selected       bool
+		expected       VFRequest
+	}{
+		{
+			containers: []v1.Container{{}},
+		},
+		{
+			containers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Limits: vfs(TotalVFsResource, 2)}},
+				{Resources: v1.ResourceRequirements{Requests: vfs(TotalVFsResource, 1)}},
+			},
+			selected: true,
+			expected: VFRequest{Mode: DriverModeAny, Count: 3},
+		},
+		{
+			containers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Limits: vfs(TotalVFsResource, 1)}},
+			},
+			initContainers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Limits: vfs(TotalVFsResource, 4)}},
+				{Resources: v1.ResourceRequirements{Limits: vfs(TotalVFsResource, 2)}},
+			},
+			selected: true,
+			expected: VFRequest{Mode: DriverModeAny, Count: 4},
+		},
+		{
+			containers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Limits: vfs(DPDKVFsResource, 2)}},
+			},
+			selected: true,
+			expected: VFRequest{Mode: DriverModeDPDK, Count: 2},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			pod := &v1.Pod{Spec: v1.PodSpec{Containers: tc.containers, InitContainers: tc.initContainers}}
+			req, selected, _ := ResourceSelector(pod)
+			if selected != tc.selected {
+				t.Fatalf("Expected selected %v, received %v", tc.selected, selected)
+			}
+			if req != tc.expected {
+				t.Errorf("Expected request %v is different from received %v", tc.expected, req)
+			}
+		})
+	}
+}
+
+func TestResourceSelectorMixedModes(t *testing.T) {
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{
+		{Resources: v1.ResourceRequirements{Limits: v1.ResourceList{
+			NetdevVFsResource: *resource.NewQuantity(1, resource.DecimalSI),
+		}}},
+		{Resources: v1.ResourceRequirements{Limits: v1.ResourceList{
+			DPDKVFsResource: *resource.NewQuantity(2, resource.DecimalSI),
+		}}},
+	}}}
+	if _, _, err := ResourceSelector(pod); err == nil {
+		t.Errorf("Expected error for pod requesting both netdev and dpdk VFs")
+	}
+}
 
+func TestFallbackSelector(t *testing.T) {
+	selector := FallbackSelector(ResourceSelector, NetworkSelector)
+	pod := &v1.Pod{}
+	pod.SetAnnotations(map[string]string{"networks": "sriov"})
+	req, selected, _ := selector(pod)
+	if !selected || req.Count != 1 {
+		t.Errorf("Expected that annotated pod will request a single VF, received %v", req)
+	}
+	pod.Spec.Containers = []v1.Container{{Resources: v1.ResourceRequirements{
+		Limits: v1.ResourceList{TotalVFsResource: *resource.NewQuantity(2, resource.DecimalSI)},
+	}}}
+	req, selected, _ = selector(pod)
+	if !selected || req.Count != 2 {
+		t.Errorf("Expected that container limits take precedence over annotation, received %v", req)
+	}
 }
@@ -0,0 +1,90 @@
+package extender
+
+import (
+	"log"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// Simulate projects placement of pod replicas using the same checks as filter and scores as
+// prioritize. It works on a snapshot of allocations and promises and doesn't modify extender state.
+func (ext *Extender) Simulate(args *SimulateArgs) (interface{}, error) {
+	log.Printf("Simulate called with pod %s/%s and %d replicas", args.Pod.Namespace, args.Pod.Name, args.Replicas)
+	result := &SimulateResult{Placements: []ReplicaPlacement{}, FailedNodes: FailedNodesMap{}}
+	req, selected, err := ext.selector(&args.Pod)
+	if err != nil {
+		return nil, err
+	}
+	if !selected {
+		result.Error = "Pod doesn't require VFs."
+		return result, nil
+	}
+	topo, err := podTopology(&args.Pod)
+	if err != nil {
+		return nil, err
+	}
+	nodes := args.Nodes
+	if nodes == nil || len(nodes.Items) == 0 {
+		var err error
+		if nodes, err = ext.client.Core().Nodes().List(meta_v1.ListOptions{}); err != nil {
+			return nil, err
+		}
+	}
+	ext.Lock()
+	defer ext.Unlock()
+	allocated, promised := ext.snapshot()
+	// simulated is a number of VFs of replicas placed on every node
+	simulated := map[string]int64{}
+	for replica := 0; replica < args.Replicas; replica++ {
+		var best *v1.Node
+		var bestScore int64
+		for i := range nodes.Items {
+			node := &nodes.Items[i]
+			if _, exists := allocated[node.Name]; !exists {
+				allocated[node.Name] = v1.ResourceList{}
+			}
+			if rule, reason, _ := ext.fitNode(node, req, topo, allocated[node.Name], promised, simulated[node.Name]); rule != "" {
+				result.FailedNodes[node.Name] = reason
+				continue
+			}
+			score, err := scoreNode(node, req, allocated[node.Name], promised)
+			if err != nil {
+				return nil, err
+			}
+			if best == nil || score > bestScore {
+				best, bestScore = node, score
+			}
+		}
+		if best == nil {
+			unschedulable := replica
+			result.FirstUnschedulable = &unschedulable
+			result.Error = "No nodes have available VFs."
+			return result, nil
+		}
+		result.FailedNodes = FailedNodesMap{}
+		result.Placements = append(result.Placements, ReplicaPlacement{
+			Replica: replica, Node: best.Name, Score: bestScore})
+		simulated[best.Name] += req.Count
+		for _, resName := range req.Resources() {
+			quantity := allocated[best.Name][resName]
+			quantity.Add(*resource.NewQuantity(req.Count, resource.DecimalSI))
+			allocated[best.Name][resName] = quantity
+		}
+	}
+	return result, nil
+}
+
+// snapshot returns a copy of allocated VFs and a number of promised VFs. Must be called
+// with extender lock held.
+func (ext *Extender) snapshot() (map[string]v1.ResourceList, *resource.Quantity) {
+	allocated := make(map[string]v1.ResourceList, len(ext.allocatedVFs))
+	for node, resources := range ext.allocatedVFs {
+		allocated[node] = v1.ResourceList{}
+		for resName, quantity := range resources {
+			allocated[node][resName] = quantity.DeepCopy()
+		}
+	}
+	return allocated, ext.promises.PromisesCount()
+}
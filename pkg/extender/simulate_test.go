@@ -0,0 +1,116 @@
+package extender
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+func TestSimulate(t *testing.T) {
+	testCases := []struct {
+		nodesResources     []int64
+		allocated          []int64
+		promised           int64
+		replicas           int
+		expectedNodes      []string
+		firstUnschedulable int
+	}{
+		{
+			nodesResources:     []int64{2, 3},
+			allocated:          []int64{0, 0},
+			replicas:           4,
+			expectedNodes:      []string{"1", "0", "1", "0"},
+			firstUnschedulable: -1,
+		},
+		{
+			nodesResources:     []int64{2, 3},
+			allocated:          []int64{1, 2},
+			replicas:           3,
+			expectedNodes:      []string{"0", "1"},
+			firstUnschedulable: 2,
+		},
+		{
+			nodesResources:     []int64{2, 2},
+			allocated:          []int64{0, 0},
+			promised:           2,
+			replicas:           1,
+			expectedNodes:      []string{},
+			firstUnschedulable: 0,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ext := NewExtender(nil)
+			for node, allocated := range tc.allocated {
+				ext.allocated(strconv.Itoa(node))[TotalVFsResource] = *resource.NewQuantity(allocated, resource.DecimalSI)
+			}
+			if tc.promised > 0 {
+				ext.promises.MakePromise(types.UID("promised"), tc.promised)
+			}
+			extenderArgs := makeExtenderArgs(tc.nodesResources)
+			resultInterface, err := ext.Simulate(&SimulateArgs{
+				Pod:      extenderArgs.Pod,
+				Replicas: tc.replicas,
+				Nodes:    extenderArgs.Nodes,
+			})
+			require.NoError(t, err)
+			result := resultInterface.(*SimulateResult)
+			nodes := []string{}
+			for _, placement := range result.Placements {
+				nodes = append(nodes, placement.Node)
+			}
+			require.Equal(t, tc.expectedNodes, nodes)
+			if tc.firstUnschedulable < 0 {
+				require.Nil(t, result.FirstUnschedulable)
+				require.Empty(t, result.Error)
+			} else {
+				require.NotNil(t, result.FirstUnschedulable)
+				require.Equal(t, tc.firstUnschedulable, *result.FirstUnschedulable)
+				require.Len(t, result.FailedNodes, len(tc.nodesResources))
+			}
+			for node, allocated := range tc.allocated {
+				quantity := ext.allocatedVFs[strconv.Itoa(node)][TotalVFsResource]
+				require.Equal(t, allocated, quantity.Value(), "simulation must not change allocations")
+			}
+			promised := ext.promises.PromisesCount()
+			require.Equal(t, tc.promised, promised.Value(), "simulation must not make promises")
+		})
+	}
+}
+
+func TestSimulateLinkDown(t *testing.T) {
+	ext := NewExtender(nil)
+	extenderArgs := makeExtenderArgs([]int64{4, 2})
+	setLinkDown(&extenderArgs.Nodes.Items[0], nodestate.AllPFLinksDownReason, "Links of all PFs are down: ens1f0")
+	resultInterface, err := ext.Simulate(&SimulateArgs{Pod: extenderArgs.Pod, Replicas: 3, Nodes: extenderArgs.Nodes})
+	require.NoError(t, err)
+	result := resultInterface.(*SimulateResult)
+	require.Equal(t, []ReplicaPlacement{{Replica: 0, Node: "1", Score: 2}, {Replica: 1, Node: "1", Score: 1}}, result.Placements)
+	require.Equal(t, 2, *result.FirstUnschedulable)
+	require.Equal(t, "Links of all PFs are down: ens1f0", result.FailedNodes["0"])
+}
+
+func TestSimulateTopology(t *testing.T) {
+	ext := NewExtender(nil)
+	ext.SetNodeStates(fakeNodeStates{
+		"0": makeNodeState(makeStatePF("ens2f0", 0, netdevVF, netdevVF, netdevVF)),
+		"1": makeNodeState(makeStatePF("ens1f0", 0, netdevVF, netdevVF), makeStatePF("ens2f0", 0, netdevVF)),
+	})
+	extenderArgs := makeExtenderArgs([]int64{4, 4})
+	extenderArgs.Pod.Annotations[PFAnnotation] = "ens1f*"
+	resultInterface, err := ext.Simulate(&SimulateArgs{Pod: extenderArgs.Pod, Replicas: 3, Nodes: extenderArgs.Nodes})
+	require.NoError(t, err)
+	result := resultInterface.(*SimulateResult)
+	// only two VFs of the matching PF can be placed although the node has more
+	require.Equal(t, []ReplicaPlacement{{Replica: 0, Node: "1", Score: 4}, {Replica: 1, Node: "1", Score: 3}}, result.Placements)
+	require.Equal(t, 2, *result.FirstUnschedulable)
+	require.Equal(t, FailedNodesMap{
+		"0": "No PFs ens1f*",
+		"1": "Not sufficient number of usable VFs on PFs ens1f*. Usable: 0",
+	}, result.FailedNodes)
+}
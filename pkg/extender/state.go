@@ -0,0 +1,180 @@
+package extender
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const topNamespacesLimit = 10
+
+// PoolState is accounting of VFs in a single pool, node resource VFs are allocated from.
+type PoolState struct {
+	Pool v1.ResourceName
+	// Total is a capacity reported by discovery
+	Total int64
+	// Reserved is a part of capacity that is not allocatable
+	Reserved int64
+	// Allocated to pods bound to nodes
+	Allocated int64
+	// Promised to pods that passed filter but not yet bound
+	Promised int64
+	// Free VFs that can be used by new pods
+	Free int64
+}
+
+// NodeState is accounting of VFs on a node.
+type NodeState struct {
+	Node  string
+	Pools []PoolState
+}
+
+// NamespaceUsage is a number of VFs allocated to pods in a namespace.
+type NamespaceUsage struct {
+	Namespace string
+	Allocated int64
+}
+
+// ClusterState is accounting of VFs in the whole cluster.
+type ClusterState struct {
+	Nodes []NodeState
+	// Pools summarizes pools of all nodes, promised VFs are counted once
+	Pools []PoolState
+	// TopNamespaces consuming most of VFs
+	TopNamespaces []NamespaceUsage
+}
+
+// PoolLister returns names of pools that can be requested by pods, e.g. configured in rules.
+type PoolLister func() []v1.ResourceName
+
+// AddPools makes cluster state report pools of the lister on every node that has them in
+// capacity or allocatable, even if nothing is allocated from them yet.
+func (ext *Extender) AddPools(lister PoolLister) {
+	ext.pools = append(ext.pools, lister)
+}
+
+// State reports allocated, promised and free VFs of every node and pool.
+func (ext *Extender) State() (*ClusterState, error) {
+	nodes, err := ext.client.Core().Nodes().List(meta_v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return ext.state(nodes.Items), nil
+}
+
+func (ext *Extender) state(nodes []v1.Node) *ClusterState {
+	ext.Lock()
+	defer ext.Unlock()
+	promised := ext.promises.PromisesCount().Value()
+	state := &ClusterState{Nodes: []NodeState{}, Pools: []PoolState{}, TopNamespaces: []NamespaceUsage{}}
+	cluster := map[v1.ResourceName]*PoolState{}
+	known := map[v1.ResourceName]bool{}
+	for _, lister := range ext.pools {
+		for _, pool := range lister() {
+			known[pool] = true
+		}
+	}
+	for i := range nodes {
+		node := &nodes[i]
+		allocated := ext.allocated(node.Name)
+		pools := map[v1.ResourceName]bool{}
+		for _, resources := range []v1.ResourceList{node.Status.Capacity, node.Status.Allocatable} {
+			for resName := range resources {
+				if isVFResource(resName) || known[resName] {
+					pools[resName] = true
+				}
+			}
+		}
+		for resName := range allocated {
+			pools[resName] = true
+		}
+		nodeState := NodeState{Node: node.Name, Pools: []PoolState{}}
+		for _, pool := range sortedResources(pools) {
+			capacity := node.Status.Capacity[pool]
+			allocatable := node.Status.Allocatable[pool]
+			used := allocated[pool]
+			poolState := PoolState{
+				Pool:      pool,
+				Total:     capacity.Value(),
+				Reserved:  capacity.Value() - allocatable.Value(),
+				Allocated: used.Value(),
+				Promised:  promised,
+				Free:      allocatable.Value() - used.Value() - promised,
+			}
+			nodeState.Pools = append(nodeState.Pools, poolState)
+			if _, exists := cluster[pool]; !exists {
+				cluster[pool] = &PoolState{Pool: pool, Promised: promised, Free: -promised}
+			}
+			cluster[pool].Total += poolState.Total
+			cluster[pool].Reserved += poolState.Reserved
+			cluster[pool].Allocated += poolState.Allocated
+			cluster[pool].Free += allocatable.Value() - used.Value()
+		}
+		state.Nodes = append(state.Nodes, nodeState)
+	}
+	sort.Slice(state.Nodes, func(i, j int) bool {
+		return state.Nodes[i].Node < state.Nodes[j].Node
+	})
+	pools := map[v1.ResourceName]bool{}
+	for pool := range cluster {
+		pools[pool] = true
+	}
+	for _, pool := range sortedResources(pools) {
+		state.Pools = append(state.Pools, *cluster[pool])
+	}
+
+	namespaces := map[string]int64{}
+	for _, alloc := range ext.allocations {
+		namespaces[alloc.namespace] += alloc.req.Count
+	}
+	for namespace, count := range namespaces {
+		state.TopNamespaces = append(state.TopNamespaces, NamespaceUsage{Namespace: namespace, Allocated: count})
+	}
+	sort.Slice(state.TopNamespaces, func(i, j int) bool {
+		if state.TopNamespaces[i].Allocated == state.TopNamespaces[j].Allocated {
+			return state.TopNamespaces[i].Namespace < state.TopNamespaces[j].Namespace
+		}
+		return state.TopNamespaces[i].Allocated > state.TopNamespaces[j].Allocated
+	})
+	if len(state.TopNamespaces) > topNamespacesLimit {
+		state.TopNamespaces = state.TopNamespaces[:topNamespacesLimit]
+	}
+	return state
+}
+
+func sortedResources(resources map[v1.ResourceName]bool) []v1.ResourceName {
+	sorted := make([]v1.ResourceName, 0, len(resources))
+	for resName := range resources {
+		sorted = append(sorted, resName)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// WriteStateTable writes cluster state as human readable tables.
+func WriteStateTable(out io.Writer, state *ClusterState) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tPOOL\tTOTAL\tRESERVED\tALLOCATED\tPROMISED\tFREE")
+	for _, node := range state.Nodes {
+		for _, pool := range node.Pools {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n",
+				node.Node, pool.Pool, pool.Total, pool.Reserved, pool.Allocated, pool.Promised, pool.Free)
+		}
+	}
+	for _, pool := range state.Pools {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n",
+			"*", pool.Pool, pool.Total, pool.Reserved, pool.Allocated, pool.Promised, pool.Free)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "NAMESPACE\tALLOCATED")
+	for _, namespace := range state.TopNamespaces {
+		fmt.Fprintf(w, "%s\t%d\n", namespace.Namespace, namespace.Allocated)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,67 @@
+package extender
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+func TestState(t *testing.T) {
+	ext := NewExtender(nil)
+	nodes := []v1.Node{makeNode(0, 4), makeNode(1, 2)}
+	nodes[0].Status.Capacity = v1.ResourceList{TotalVFsResource: *resource.NewQuantity(5, resource.DecimalSI)}
+	nodes[1].Status.Capacity = v1.ResourceList{TotalVFsResource: *resource.NewQuantity(2, resource.DecimalSI)}
+	for _, pod := range []*v1.Pod{makeVFPod("a", "0", 2), makeVFPod("b", "0", 1), makeVFPod("c", "1", 1)} {
+		if pod.Name == "c" {
+			pod.Namespace = "other"
+		}
+		req, _, _ := ext.selector(pod)
+		ext.allocate(pod, req)
+	}
+	ext.promises.MakePromise(types.UID("pending"), 1)
+
+	state := ext.state(nodes)
+	require.Equal(t, []NodeState{
+		{Node: "0", Pools: []PoolState{
+			{Pool: TotalVFsResource, Total: 5, Reserved: 1, Allocated: 3, Promised: 1, Free: 0},
+		}},
+		{Node: "1", Pools: []PoolState{
+			{Pool: TotalVFsResource, Total: 2, Reserved: 0, Allocated: 1, Promised: 1, Free: 0},
+		}},
+	}, state.Nodes)
+	require.Equal(t, []PoolState{
+		{Pool: TotalVFsResource, Total: 7, Reserved: 1, Allocated: 4, Promised: 1, Free: 1},
+	}, state.Pools)
+	require.Equal(t, []NamespaceUsage{
+		{Namespace: "default", Allocated: 3},
+		{Namespace: "other", Allocated: 1},
+	}, state.TopNamespaces)
+
+	var out bytes.Buffer
+	require.NoError(t, WriteStateTable(&out, state))
+	lines := strings.Split(out.String(), "\n")
+	require.Equal(t, []string{"NODE", "POOL", "TOTAL", "RESERVED", "ALLOCATED", "PROMISED", "FREE"}, strings.Fields(lines[0]))
+	require.Equal(t, []string{"*", "totalvfs", "7", "1", "4", "1", "1"}, strings.Fields(lines[3]))
+}
+
+func TestStateListsPools(t *testing.T) {
+	ext := NewExtender(nil)
+	ext.AddPools(func() []v1.ResourceName { return []v1.ResourceName{"pool-a", "pool-b"} })
+	node := makeNode(0, 4)
+	node.Status.Allocatable["pool-a"] = *resource.NewQuantity(2, resource.DecimalSI)
+	node.Status.Allocatable[v1.ResourceCPU] = *resource.NewQuantity(8, resource.DecimalSI)
+	node.Status.Capacity = node.Status.Allocatable
+
+	state := ext.state([]v1.Node{node})
+	require.Equal(t, []NodeState{
+		{Node: "0", Pools: []PoolState{
+			{Pool: "pool-a", Total: 2, Free: 2},
+			{Pool: TotalVFsResource, Total: 4, Free: 4},
+		}},
+	}, state.Nodes)
+}
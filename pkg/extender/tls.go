@@ -0,0 +1,169 @@
+package extender
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecurityOptions configure TLS serving and authentication of extender clients.
+type SecurityOptions struct {
+	// CertFile and KeyFile enable TLS, both are reloaded once changed on disk.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is a CA bundle client certificates are verified against.
+	ClientCAFile string
+	// TokenFile contains a bearer token clients have to present.
+	TokenFile string
+}
+
+// unauthenticatedPaths are used by kubelet probes, which present neither certificates nor tokens.
+var unauthenticatedPaths = map[string]bool{"/healthz": true, "/readyz": true}
+
+// SecureServer configures TLS of a server and wraps its handler to authenticate clients.
+// Server has to be started with ListenAndServeTLS("", "") if CertFile is set.
+func SecureServer(srv *http.Server, opts SecurityOptions) error {
+	tlsConfig, token, err := loadSecurity(opts)
+	if err != nil {
+		return err
+	}
+	srv.TLSConfig = tlsConfig
+	if opts.ClientCAFile != "" || token != nil {
+		srv.Handler = authenticate(srv.Handler, opts.ClientCAFile != "", token)
+	}
+	return nil
+}
+
+// loadSecurity returns TLS configuration, nil if TLS is disabled, and a bearer token, nil if not required.
+func loadSecurity(opts SecurityOptions) (*tls.Config, []byte, error) {
+	if (opts.CertFile == "") != (opts.KeyFile == "") {
+		return nil, nil, fmt.Errorf("both certificate and key files are required")
+	}
+	if opts.ClientCAFile != "" && opts.CertFile == "" {
+		return nil, nil, fmt.Errorf("client certificates can be verified only if TLS is enabled")
+	}
+	if opts.TokenFile != "" && opts.CertFile == "" {
+		return nil, nil, fmt.Errorf("bearer token can be required only if TLS is enabled")
+	}
+	var token []byte
+	if opts.TokenFile != "" {
+		data, err := ioutil.ReadFile(opts.TokenFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading token: %v", err)
+		}
+		if token = []byte(strings.TrimSpace(string(data))); len(token) == 0 {
+			return nil, nil, fmt.Errorf("token file %s is empty", opts.TokenFile)
+		}
+	}
+	if opts.CertFile == "" {
+		return nil, token, nil
+	}
+	reloader := &certReloader{certFile: opts.CertFile, keyFile: opts.KeyFile}
+	if _, err := reloader.GetCertificate(nil); err != nil {
+		return nil, nil, err
+	}
+	tlsConfig := &tls.Config{GetCertificate: reloader.GetCertificate}
+	if opts.ClientCAFile != "" {
+		data, err := ioutil.ReadFile(opts.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading client CA bundle: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, nil, fmt.Errorf("no certificates found in %s", opts.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		// certificates are required by authenticate, so probes can connect without them
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, token, nil
+}
+
+// authenticate rejects requests without verified client certificate or valid bearer token.
+func authenticate(h http.Handler, requireCert bool, token []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unauthenticatedPaths[r.URL.Path] {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if err := checkCredentials(r.TLS, r.Header.Get("Authorization"), requireCert, token); err != nil {
+			log.Printf("request to %s from %s rejected: %v\n", r.URL.Path, r.RemoteAddr, err)
+			if token != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			writeError(w, http.StatusUnauthorized, ErrorCodeUnauthorized, "%v", err)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// checkCredentials verifies client certificate and value of authorization header.
+func checkCredentials(state *tls.ConnectionState, authorization string, requireCert bool, token []byte) error {
+	if requireCert && (state == nil || len(state.VerifiedChains) == 0) {
+		return fmt.Errorf("client certificate required")
+	}
+	if token != nil {
+		const prefix = "Bearer "
+		if !strings.HasPrefix(authorization, prefix) ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(authorization, prefix)), token) != 1 {
+			return fmt.Errorf("invalid bearer token")
+		}
+	}
+	return nil
+}
+
+// certReloader serves a certificate and loads it again once certificate or key file is modified.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.Lock()
+	defer c.Unlock()
+	var modTime time.Time
+	for _, path := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return c.keep(err)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	if c.cert != nil && !modTime.After(c.modTime) {
+		return c.cert, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return c.keep(err)
+	}
+	if c.cert != nil {
+		log.Printf("certificate %s reloaded\n", c.certFile)
+	}
+	c.cert, c.modTime = &cert, modTime
+	return c.cert, nil
+}
+
+// keep serves previously loaded certificate if a new one can't be loaded,
+// e.g. if only one of the files was updated so far.
+func (c *certReloader) keep(err error) (*tls.Certificate, error) {
+	if c.cert == nil {
+		return nil, fmt.Errorf("error loading certificate %s: %v", c.certFile, err)
+	}
+	log.Printf("error reloading certificate %s, using previous one: %v\n", c.certFile, err)
+	return c.cert, nil
+}
@@ -0,0 +1,160 @@
+package extender
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert writes a certificate signed by parent, or a self-signed CA if parent is nil.
+func writeCert(t *testing.T, dir, name string, parent *tls.Certificate) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := template, interface{}(key)
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600))
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	cert.Leaf, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &cert
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extender-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	first := writeCert(t, dir, "server", nil)
+	reloader := &certReloader{certFile: filepath.Join(dir, "server.crt"), keyFile: filepath.Join(dir, "server.key")}
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, first.Certificate, cert.Certificate)
+
+	second := writeCert(t, dir, "server", nil)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(reloader.certFile, future, future))
+	cert, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, second.Certificate, cert.Certificate)
+
+	require.NoError(t, ioutil.WriteFile(reloader.keyFile, []byte("broken"), 0600))
+	require.NoError(t, os.Chtimes(reloader.keyFile, future.Add(time.Minute), future.Add(time.Minute)))
+	cert, err = reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, second.Certificate, cert.Certificate)
+}
+
+func TestSecureServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extender-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ca := writeCert(t, dir, "ca", nil)
+	writeCert(t, dir, "server", ca)
+	client := writeCert(t, dir, "client", ca)
+	other := writeCert(t, dir, "other", writeCert(t, dir, "otherca", nil))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("secret\n"), 0600))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	srv := &http.Server{Handler: handler}
+	require.NoError(t, SecureServer(srv, SecurityOptions{
+		CertFile:     filepath.Join(dir, "server.crt"),
+		KeyFile:      filepath.Join(dir, "server.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+		TokenFile:    filepath.Join(dir, "token"),
+	}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(tls.NewListener(ln, srv.TLSConfig))
+	defer srv.Close()
+	url := "https://" + ln.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	for i, tc := range []struct {
+		path  string
+		cert  *tls.Certificate
+		token string
+		code  int
+	}{
+		{"/filter", client, "secret", http.StatusOK},
+		{"/filter", client, "wrong", http.StatusUnauthorized},
+		{"/filter", client, "", http.StatusUnauthorized},
+		{"/filter", nil, "secret", http.StatusUnauthorized},
+		{"/healthz", nil, "", http.StatusOK},
+		{"/filter", other, "secret", 0},
+	} {
+		tlsConfig := &tls.Config{RootCAs: roots}
+		if tc.cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*tc.cert}
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		req, err := http.NewRequest("POST", url+tc.path, nil)
+		require.NoError(t, err)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		resp, err := httpClient.Do(req)
+		if tc.code == 0 {
+			// with TLS 1.3 client learns that its certificate was rejected only after handshake
+			if err == nil {
+				resp.Body.Close()
+				require.Equal(t, http.StatusUnauthorized, resp.StatusCode, "case %d", i)
+			}
+			continue
+		}
+		require.NoError(t, err, "case %d", i)
+		resp.Body.Close()
+		require.Equal(t, tc.code, resp.StatusCode, "case %d", i)
+	}
+}
+
+func TestSecureServerValidation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extender-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	token := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(token, []byte("secret"), 0600))
+	for i, opts := range []SecurityOptions{
+		{CertFile: "server.crt"},
+		{ClientCAFile: "ca.crt"},
+		{TokenFile: "/nonexistent"},
+		{TokenFile: token},
+	} {
+		require.Error(t, SecureServer(&http.Server{}, opts), "case %d", i)
+	}
+}
@@ -0,0 +1,179 @@
+package extender
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+const (
+	// PFAnnotation restricts VFs of a pod to PFs with names matching a shell pattern, e.g. ens1f*.
+	PFAnnotation = "vfpf"
+	// NUMANodeAnnotation restricts VFs of a pod to PFs attached to a NUMA node.
+	NUMANodeAnnotation = "vfnuma"
+)
+
+// NodeStates provides SriovNodeStates written by discovery, nodestate.Informer implements it.
+type NodeStates interface {
+	// Get returns state of a node and false if discovery didn't write it.
+	Get(node string) (*nodestate.SriovNodeState, bool, error)
+}
+
+// SetNodeStates makes extender filter pods with PFAnnotation or NUMANodeAnnotation by PFs
+// of every node, otherwise these annotations are ignored.
+func (ext *Extender) SetNodeStates(states NodeStates) {
+	ext.states = states
+}
+
+// topology is a placement of VFs requested by a pod, zero value accepts any PF.
+type topology struct {
+	pf       string
+	hasNUMA  bool
+	numaNode int
+}
+
+func podTopology(pod *v1.Pod) (topology, error) {
+	var t topology
+	if pattern, exists := pod.Annotations[PFAnnotation]; exists {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return t, fmt.Errorf("invalid %s annotation %q of pod %s/%s: %v", PFAnnotation, pattern, pod.Namespace, pod.Name, err)
+		}
+		t.pf = pattern
+	}
+	if value, exists := pod.Annotations[NUMANodeAnnotation]; exists {
+		numaNode, err := strconv.Atoi(value)
+		if err != nil || numaNode < 0 {
+			return t, fmt.Errorf("invalid %s annotation %q of pod %s/%s", NUMANodeAnnotation, value, pod.Namespace, pod.Name)
+		}
+		t.hasNUMA, t.numaNode = true, numaNode
+	}
+	return t, nil
+}
+
+func (t topology) requested() bool {
+	return t.pf != "" || t.hasNUMA
+}
+
+func (t topology) matches(pf nodestate.PF) bool {
+	if t.pf != "" {
+		if matched, _ := filepath.Match(t.pf, pf.Name); !matched {
+			return false
+		}
+	}
+	return !t.hasNUMA || pf.NUMANode == t.numaNode
+}
+
+func (t topology) String() string {
+	switch {
+	case t.pf != "" && t.hasNUMA:
+		return fmt.Sprintf("PFs %s on NUMA node %d", t.pf, t.numaNode)
+	case t.hasNUMA:
+		return fmt.Sprintf("PFs on NUMA node %d", t.numaNode)
+	}
+	return "PFs " + t.pf
+}
+
+// modesOverlap returns true if VFs of one mode can be taken for a request of the other one.
+func modesOverlap(a, b DriverMode) bool {
+	return a == DriverModeAny || b == DriverModeAny || a == b
+}
+
+// usableVFs returns number of VFs of a PF that can be given to a request of the mode.
+func usableVFs(pf nodestate.PF, mode DriverMode) int64 {
+	var usable int64
+	for _, vf := range pf.VFs {
+		if vf.Usable() && (mode == DriverModeAny || DriverMode(vf.Mode) == mode) {
+			usable++
+		}
+	}
+	return usable
+}
+
+// allocatedOnPF returns number of VFs allocated on a PF of a node to requests which
+// modes overlap with the mode. Must be called with extender lock held.
+func (ext *Extender) allocatedOnPF(node, pf string, mode DriverMode) int64 {
+	var allocated int64
+	for _, alloc := range ext.allocations {
+		if alloc.node == node && alloc.pf == pf && modesOverlap(alloc.req.Mode, mode) {
+			allocated += alloc.req.Count
+		}
+	}
+	return allocated
+}
+
+// choosePF returns the first PF of a pod node matching pod topology with link up and
+// enough VFs that are neither allocated nor taken by the pod, empty if there is no such
+// PF or extender doesn't know the node state. Must be called with extender lock held.
+func (ext *Extender) choosePF(pod *v1.Pod, req VFRequest) string {
+	if ext.states == nil {
+		return ""
+	}
+	state, exists, err := ext.states.Get(pod.Spec.NodeName)
+	if err != nil {
+		log.Printf("Error getting SriovNodeState of a node %s: %v", pod.Spec.NodeName, err)
+	}
+	if err != nil || !exists {
+		return ""
+	}
+	// invalid topology is reported by filter, VFs can be taken from any PF then
+	t, _ := podTopology(pod)
+	for _, pf := range state.Status.PFs {
+		if !t.matches(pf) || pf.LinkDown {
+			continue
+		}
+		if usableVFs(pf, req.Mode)-ext.allocatedOnPF(pod.Spec.NodeName, pf.Name, req.Mode) >= req.Count {
+			return pf.Name
+		}
+	}
+	return ""
+}
+
+// checkTopology verifies that PFs matching a topology have enough usable VFs according to
+// the last discovery, PFs with links down are skipped. VFs allocated on these PFs and
+// promised VFs are not usable. It returns false if node has no matching PFs with links up,
+// and a failure reason if they don't have enough VFs. Any node passes if topology isn't
+// requested or extender doesn't know states of nodes. Must be called with extender lock held.
+func (ext *Extender) checkTopology(node string, req VFRequest, t topology, promised int64) (bool, string) {
+	if !t.requested() || ext.states == nil {
+		return true, ""
+	}
+	state, exists, err := ext.states.Get(node)
+	if err != nil {
+		log.Printf("Error getting SriovNodeState of a node %s: %v", node, err)
+	}
+	if err != nil || !exists {
+		return false, fmt.Sprintf("No SriovNodeState to find %v", t)
+	}
+	matched, up := false, false
+	var usable int64
+	for _, pf := range state.Status.PFs {
+		if !t.matches(pf) {
+			continue
+		}
+		matched = true
+		if pf.LinkDown {
+			continue
+		}
+		up = true
+		if free := usableVFs(pf, req.Mode) - ext.allocatedOnPF(node, pf.Name, req.Mode); free > 0 {
+			usable += free
+		}
+	}
+	if !matched {
+		return false, fmt.Sprintf("No %v", t)
+	}
+	if !up {
+		return false, fmt.Sprintf("Links of all %v are down", t)
+	}
+	usable -= promised
+	log.Printf("Node %s has %d usable VFs on %v", node, usable, t)
+	if usable < req.Count {
+		return true, fmt.Sprintf("Not sufficient number of usable VFs on %v. Usable: %d", t, usable)
+	}
+	return true, ""
+}
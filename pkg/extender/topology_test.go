@@ -0,0 +1,149 @@
+package extender
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/pkg/api/v1"
+
+	"github.com/Mirantis/sriov-scheduler/pkg/nodestate"
+)
+
+// fakeNodeStates serves states of nodes by name.
+type fakeNodeStates map[string]*nodestate.SriovNodeState
+
+func (f fakeNodeStates) Get(node string) (*nodestate.SriovNodeState, bool, error) {
+	state, exists := f[node]
+	return state, exists, nil
+}
+
+func makeNodeState(pfs ...nodestate.PF) *nodestate.SriovNodeState {
+	return &nodestate.SriovNodeState{Status: nodestate.SriovNodeStateStatus{PFs: pfs}}
+}
+
+func makeStatePF(name string, numaNode int, vfs ...nodestate.VF) nodestate.PF {
+	return nodestate.PF{Name: name, NUMANode: numaNode, NumVFs: int64(len(vfs)), VFs: vfs}
+}
+
+var (
+	netdevVF = nodestate.VF{Driver: "iavf", Mode: string(DriverModeNetdev)}
+	dpdkVF   = nodestate.VF{Driver: "vfio-pci", Mode: string(DriverModeDPDK)}
+	usedVF   = nodestate.VF{Driver: "iavf", Mode: string(DriverModeNetdev), InUse: true}
+)
+
+func TestPodTopology(t *testing.T) {
+	pod := makePod("first")
+	topo, err := podTopology(&pod)
+	require.NoError(t, err)
+	require.False(t, topo.requested())
+
+	pod.Annotations[PFAnnotation] = "ens1f*"
+	pod.Annotations[NUMANodeAnnotation] = "1"
+	topo, err = podTopology(&pod)
+	require.NoError(t, err)
+	require.Equal(t, topology{pf: "ens1f*", hasNUMA: true, numaNode: 1}, topo)
+	require.True(t, topo.matches(makeStatePF("ens1f0", 1)))
+	require.False(t, topo.matches(makeStatePF("ens1f0", 0)))
+	require.False(t, topo.matches(makeStatePF("ens2f0", 1)))
+
+	pod.Annotations[NUMANodeAnnotation] = "first"
+	_, err = podTopology(&pod)
+	require.Error(t, err)
+	pod.Annotations[NUMANodeAnnotation] = "0"
+	pod.Annotations[PFAnnotation] = "[ens"
+	_, err = podTopology(&pod)
+	require.Error(t, err)
+}
+
+func TestFilterTopology(t *testing.T) {
+	ext := NewExtender(nil)
+	ext.SetNodeStates(fakeNodeStates{
+		// all PFs are on another NUMA node
+		"0": makeNodeState(makeStatePF("ens1f0", 0, netdevVF, netdevVF), makeStatePF("ens2f0", 0, dpdkVF)),
+		// only one VF is usable
+		"1": makeNodeState(makeStatePF("ens1f0", 1, netdevVF, usedVF)),
+		"2": makeNodeState(makeStatePF("ens1f0", 1, netdevVF), makeStatePF("ens1f1", 1, netdevVF, usedVF)),
+		// "3" has no state
+	})
+	args := makeExtenderArgs([]int64{4, 4, 4, 4})
+	args.Pod.Annotations[NUMANodeAnnotation] = "1"
+	args.Pod.Annotations[DriverModeAnnotation] = string(DriverModeNetdev)
+	for i := range args.Nodes.Items {
+		args.Nodes.Items[i].Status.Allocatable[NetdevVFsResource] = *resource.NewQuantity(4, resource.DecimalSI)
+	}
+	ext.SetSelector(func(pod *v1.Pod) (VFRequest, bool, error) {
+		return VFRequest{Mode: DriverModeNetdev, Count: 2}, true, nil
+	})
+	resultInterface, err := ext.FilterArgs(args)
+	require.NoError(t, err)
+	result := resultInterface.(*ExtenderFilterResult)
+	require.Len(t, result.Nodes.Items, 1)
+	require.Equal(t, "2", result.Nodes.Items[0].Name)
+	require.Equal(t, FailedNodesMap{
+		"1": "Not sufficient number of usable VFs on PFs on NUMA node 1. Usable: 1",
+	}, result.FailedNodes)
+	require.Equal(t, FailedNodesMap{
+		"0": "No PFs on NUMA node 1",
+		"3": "No SriovNodeState to find PFs on NUMA node 1",
+	}, result.FailedAndUnresolvableNodes)
+
+	args.Pod.Annotations[NUMANodeAnnotation] = "-1"
+	_, err = ext.FilterArgs(args)
+	require.Error(t, err)
+}
+
+func TestCheckTopologyAllocated(t *testing.T) {
+	ext := NewExtender(nil)
+	ext.SetNodeStates(fakeNodeStates{
+		"0": makeNodeState(makeStatePF("ens1f0", 1, netdevVF, netdevVF), makeStatePF("ens2f0", 0, netdevVF, netdevVF)),
+	})
+	topo := topology{hasNUMA: true, numaNode: 1}
+	req := VFRequest{Mode: DriverModeNetdev, Count: 1}
+	hasVFs, reason := ext.checkTopology("0", req, topo, 0)
+	require.True(t, hasVFs)
+	require.Empty(t, reason)
+
+	// promised VFs may be taken from the matching PF
+	_, reason = ext.checkTopology("0", VFRequest{Mode: DriverModeNetdev, Count: 2}, topo, 1)
+	require.Equal(t, "Not sufficient number of usable VFs on PFs on NUMA node 1. Usable: 1", reason)
+
+	// the only matching PF is fully allocated, VFs of the other one don't count
+	require.Equal(t, "ens1f0", ext.allocate(makeVFPod("first", "0", 2), VFRequest{Mode: DriverModeNetdev, Count: 2}))
+	hasVFs, reason = ext.checkTopology("0", req, topo, 0)
+	require.True(t, hasVFs)
+	require.Equal(t, "Not sufficient number of usable VFs on PFs on NUMA node 1. Usable: 0", reason)
+}
+
+func TestExplainTopology(t *testing.T) {
+	ext := NewExtender(nil)
+	ext.SetNodeStates(fakeNodeStates{"0": makeNodeState(makeStatePF("ens1f0", 0, netdevVF))})
+	nodes := makeExtenderArgs([]int64{2, 2}).Nodes.Items
+	explained := ext.explain(VFRequest{Count: 1}, topology{pf: "ens1f0"}, nodes)
+	require.True(t, explained[0].Fits)
+	require.False(t, explained[1].Fits)
+	require.Equal(t, RuleTopology, explained[1].Rule)
+	require.Equal(t, "No SriovNodeState to find PFs ens1f0", explained[1].Reason)
+}
+
+func TestAllocateChoosesPF(t *testing.T) {
+	ext := NewExtender(nil)
+	ext.SetNodeStates(fakeNodeStates{
+		"0": makeNodeState(
+			makeStatePF("ens1f0", 0, netdevVF, usedVF),
+			makeStatePF("ens1f1", 0, netdevVF, netdevVF),
+		),
+	})
+	require.Equal(t, "ens1f1", ext.allocate(makeVFPod("first", "0", 2), VFRequest{Count: 2}))
+	require.Equal(t, "ens1f0", ext.allocate(makeVFPod("second", "0", 1), VFRequest{Count: 1}))
+	require.Equal(t, "", ext.allocate(makeVFPod("third", "0", 1), VFRequest{Count: 1}))
+	require.Equal(t, "", ext.allocate(makeVFPod("other", "1", 1), VFRequest{Count: 1}))
+
+	// PF stays the same once pod is accounted or recorded on the pod
+	require.Equal(t, "ens1f1", ext.allocate(makeVFPod("first", "0", 2), VFRequest{Count: 2}))
+	recorded := makeVFPod("recorded", "0", 1)
+	recorded.Annotations = map[string]string{
+		AllocationAnnotation: `{"node": "0", "pool": "totalvfs", "pf": "ens1f0", "count": 1}`,
+	}
+	require.Equal(t, "ens1f0", ext.allocate(recorded, VFRequest{Count: 1, Pool: TotalVFsResource}))
+}
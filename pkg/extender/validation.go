@@ -0,0 +1,53 @@
+package extender
+
+import "fmt"
+
+// validator is implemented by request arguments that can be checked before calling the extender.
+type validator interface {
+	validate() error
+}
+
+func (args *ExtenderArgs) validate() error {
+	if args.Pod.UID == "" {
+		return fmt.Errorf("pod uid is required")
+	}
+	if args.Nodes == nil && args.NodeNames == nil {
+		return fmt.Errorf("either nodes or node names are required")
+	}
+	return nil
+}
+
+func (args *ExtenderPreemptionArgs) validate() error {
+	if args.Pod == nil {
+		return fmt.Errorf("pod is required")
+	}
+	if args.Pod.UID == "" {
+		return fmt.Errorf("pod uid is required")
+	}
+	return nil
+}
+
+func (args *ExtenderBindingArgs) validate() error {
+	switch {
+	case args.PodUID == "":
+		return fmt.Errorf("pod uid is required")
+	case args.PodName == "" || args.PodNamespace == "":
+		return fmt.Errorf("pod name and namespace are required")
+	case args.Node == "":
+		return fmt.Errorf("node is required")
+	}
+	return nil
+}
+
+// maxSimulateReplicas bounds the work of a single simulation, every replica scans all nodes.
+const maxSimulateReplicas = 1000
+
+func (args *SimulateArgs) validate() error {
+	if args.Replicas < 1 {
+		return fmt.Errorf("at least one replica is required")
+	}
+	if args.Replicas > maxSimulateReplicas {
+		return fmt.Errorf("number of replicas can't exceed %d", maxSimulateReplicas)
+	}
+	return nil
+}
@@ -0,0 +1,1110 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/extenderpb/extender.proto
+
+package extenderpb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ExtenderArgs struct {
+	// Pod being scheduled, a v1.Pod encoded as JSON.
+	Pod []byte `protobuf:"bytes,1,opt,name=pod,proto3" json:"pod,omitempty"`
+	// Candidate nodes, v1.Node objects encoded as JSON. If empty, nodes are
+	// fetched from the API, only those in node_names if it is set.
+	Nodes                [][]byte `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	NodeNames            []string `protobuf:"bytes,3,rep,name=node_names,json=nodeNames,proto3" json:"node_names,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExtenderArgs) Reset()         { *m = ExtenderArgs{} }
+func (m *ExtenderArgs) String() string { return proto.CompactTextString(m) }
+func (*ExtenderArgs) ProtoMessage()    {}
+func (*ExtenderArgs) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{0}
+}
+
+func (m *ExtenderArgs) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExtenderArgs.Unmarshal(m, b)
+}
+func (m *ExtenderArgs) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExtenderArgs.Marshal(b, m, deterministic)
+}
+func (m *ExtenderArgs) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExtenderArgs.Merge(m, src)
+}
+func (m *ExtenderArgs) XXX_Size() int {
+	return xxx_messageInfo_ExtenderArgs.Size(m)
+}
+func (m *ExtenderArgs) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExtenderArgs.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExtenderArgs proto.InternalMessageInfo
+
+func (m *ExtenderArgs) GetPod() []byte {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+func (m *ExtenderArgs) GetNodes() [][]byte {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *ExtenderArgs) GetNodeNames() []string {
+	if m != nil {
+		return m.NodeNames
+	}
+	return nil
+}
+
+type FilterResult struct {
+	// Nodes where the pod can be scheduled.
+	NodeNames []string `protobuf:"bytes,1,rep,name=node_names,json=nodeNames,proto3" json:"node_names,omitempty"`
+	// Nodes where the pod can't be scheduled and failure messages.
+	FailedNodes map[string]string `protobuf:"bytes,2,rep,name=failed_nodes,json=failedNodes,proto3" json:"failed_nodes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Nodes where the pod can't be scheduled even if other pods are preempted.
+	FailedAndUnresolvableNodes map[string]string `protobuf:"bytes,3,rep,name=failed_and_unresolvable_nodes,json=failedAndUnresolvableNodes,proto3" json:"failed_and_unresolvable_nodes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Error message indicating failure.
+	Error                string   `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FilterResult) Reset()         { *m = FilterResult{} }
+func (m *FilterResult) String() string { return proto.CompactTextString(m) }
+func (*FilterResult) ProtoMessage()    {}
+func (*FilterResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{1}
+}
+
+func (m *FilterResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FilterResult.Unmarshal(m, b)
+}
+func (m *FilterResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FilterResult.Marshal(b, m, deterministic)
+}
+func (m *FilterResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FilterResult.Merge(m, src)
+}
+func (m *FilterResult) XXX_Size() int {
+	return xxx_messageInfo_FilterResult.Size(m)
+}
+func (m *FilterResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_FilterResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FilterResult proto.InternalMessageInfo
+
+func (m *FilterResult) GetNodeNames() []string {
+	if m != nil {
+		return m.NodeNames
+	}
+	return nil
+}
+
+func (m *FilterResult) GetFailedNodes() map[string]string {
+	if m != nil {
+		return m.FailedNodes
+	}
+	return nil
+}
+
+func (m *FilterResult) GetFailedAndUnresolvableNodes() map[string]string {
+	if m != nil {
+		return m.FailedAndUnresolvableNodes
+	}
+	return nil
+}
+
+func (m *FilterResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type HostPriority struct {
+	Host                 string   `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Score                int64    `protobuf:"varint,2,opt,name=score,proto3" json:"score,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HostPriority) Reset()         { *m = HostPriority{} }
+func (m *HostPriority) String() string { return proto.CompactTextString(m) }
+func (*HostPriority) ProtoMessage()    {}
+func (*HostPriority) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{2}
+}
+
+func (m *HostPriority) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HostPriority.Unmarshal(m, b)
+}
+func (m *HostPriority) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HostPriority.Marshal(b, m, deterministic)
+}
+func (m *HostPriority) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HostPriority.Merge(m, src)
+}
+func (m *HostPriority) XXX_Size() int {
+	return xxx_messageInfo_HostPriority.Size(m)
+}
+func (m *HostPriority) XXX_DiscardUnknown() {
+	xxx_messageInfo_HostPriority.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HostPriority proto.InternalMessageInfo
+
+func (m *HostPriority) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *HostPriority) GetScore() int64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+type PrioritizeResult struct {
+	Priorities           []*HostPriority `protobuf:"bytes,1,rep,name=priorities,proto3" json:"priorities,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *PrioritizeResult) Reset()         { *m = PrioritizeResult{} }
+func (m *PrioritizeResult) String() string { return proto.CompactTextString(m) }
+func (*PrioritizeResult) ProtoMessage()    {}
+func (*PrioritizeResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{3}
+}
+
+func (m *PrioritizeResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrioritizeResult.Unmarshal(m, b)
+}
+func (m *PrioritizeResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrioritizeResult.Marshal(b, m, deterministic)
+}
+func (m *PrioritizeResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrioritizeResult.Merge(m, src)
+}
+func (m *PrioritizeResult) XXX_Size() int {
+	return xxx_messageInfo_PrioritizeResult.Size(m)
+}
+func (m *PrioritizeResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrioritizeResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrioritizeResult proto.InternalMessageInfo
+
+func (m *PrioritizeResult) GetPriorities() []*HostPriority {
+	if m != nil {
+		return m.Priorities
+	}
+	return nil
+}
+
+type GetStateRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetStateRequest) Reset()         { *m = GetStateRequest{} }
+func (m *GetStateRequest) String() string { return proto.CompactTextString(m) }
+func (*GetStateRequest) ProtoMessage()    {}
+func (*GetStateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{4}
+}
+
+func (m *GetStateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStateRequest.Unmarshal(m, b)
+}
+func (m *GetStateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStateRequest.Marshal(b, m, deterministic)
+}
+func (m *GetStateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStateRequest.Merge(m, src)
+}
+func (m *GetStateRequest) XXX_Size() int {
+	return xxx_messageInfo_GetStateRequest.Size(m)
+}
+func (m *GetStateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStateRequest proto.InternalMessageInfo
+
+type PoolState struct {
+	// Node resource VFs are allocated from.
+	Pool                 string   `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	Total                int64    `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Reserved             int64    `protobuf:"varint,3,opt,name=reserved,proto3" json:"reserved,omitempty"`
+	Allocated            int64    `protobuf:"varint,4,opt,name=allocated,proto3" json:"allocated,omitempty"`
+	Promised             int64    `protobuf:"varint,5,opt,name=promised,proto3" json:"promised,omitempty"`
+	Free                 int64    `protobuf:"varint,6,opt,name=free,proto3" json:"free,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PoolState) Reset()         { *m = PoolState{} }
+func (m *PoolState) String() string { return proto.CompactTextString(m) }
+func (*PoolState) ProtoMessage()    {}
+func (*PoolState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{5}
+}
+
+func (m *PoolState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PoolState.Unmarshal(m, b)
+}
+func (m *PoolState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PoolState.Marshal(b, m, deterministic)
+}
+func (m *PoolState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PoolState.Merge(m, src)
+}
+func (m *PoolState) XXX_Size() int {
+	return xxx_messageInfo_PoolState.Size(m)
+}
+func (m *PoolState) XXX_DiscardUnknown() {
+	xxx_messageInfo_PoolState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PoolState proto.InternalMessageInfo
+
+func (m *PoolState) GetPool() string {
+	if m != nil {
+		return m.Pool
+	}
+	return ""
+}
+
+func (m *PoolState) GetTotal() int64 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+func (m *PoolState) GetReserved() int64 {
+	if m != nil {
+		return m.Reserved
+	}
+	return 0
+}
+
+func (m *PoolState) GetAllocated() int64 {
+	if m != nil {
+		return m.Allocated
+	}
+	return 0
+}
+
+func (m *PoolState) GetPromised() int64 {
+	if m != nil {
+		return m.Promised
+	}
+	return 0
+}
+
+func (m *PoolState) GetFree() int64 {
+	if m != nil {
+		return m.Free
+	}
+	return 0
+}
+
+type NodeState struct {
+	Node                 string       `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Pools                []*PoolState `protobuf:"bytes,2,rep,name=pools,proto3" json:"pools,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *NodeState) Reset()         { *m = NodeState{} }
+func (m *NodeState) String() string { return proto.CompactTextString(m) }
+func (*NodeState) ProtoMessage()    {}
+func (*NodeState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{6}
+}
+
+func (m *NodeState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeState.Unmarshal(m, b)
+}
+func (m *NodeState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeState.Marshal(b, m, deterministic)
+}
+func (m *NodeState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeState.Merge(m, src)
+}
+func (m *NodeState) XXX_Size() int {
+	return xxx_messageInfo_NodeState.Size(m)
+}
+func (m *NodeState) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeState proto.InternalMessageInfo
+
+func (m *NodeState) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+func (m *NodeState) GetPools() []*PoolState {
+	if m != nil {
+		return m.Pools
+	}
+	return nil
+}
+
+type NamespaceUsage struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Allocated            int64    `protobuf:"varint,2,opt,name=allocated,proto3" json:"allocated,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NamespaceUsage) Reset()         { *m = NamespaceUsage{} }
+func (m *NamespaceUsage) String() string { return proto.CompactTextString(m) }
+func (*NamespaceUsage) ProtoMessage()    {}
+func (*NamespaceUsage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{7}
+}
+
+func (m *NamespaceUsage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NamespaceUsage.Unmarshal(m, b)
+}
+func (m *NamespaceUsage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NamespaceUsage.Marshal(b, m, deterministic)
+}
+func (m *NamespaceUsage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NamespaceUsage.Merge(m, src)
+}
+func (m *NamespaceUsage) XXX_Size() int {
+	return xxx_messageInfo_NamespaceUsage.Size(m)
+}
+func (m *NamespaceUsage) XXX_DiscardUnknown() {
+	xxx_messageInfo_NamespaceUsage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NamespaceUsage proto.InternalMessageInfo
+
+func (m *NamespaceUsage) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *NamespaceUsage) GetAllocated() int64 {
+	if m != nil {
+		return m.Allocated
+	}
+	return 0
+}
+
+type ClusterState struct {
+	Nodes []*NodeState `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	// Pools of all nodes, promised VFs are counted once.
+	Pools []*PoolState `protobuf:"bytes,2,rep,name=pools,proto3" json:"pools,omitempty"`
+	// Namespaces consuming most of VFs.
+	TopNamespaces        []*NamespaceUsage `protobuf:"bytes,3,rep,name=top_namespaces,json=topNamespaces,proto3" json:"top_namespaces,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ClusterState) Reset()         { *m = ClusterState{} }
+func (m *ClusterState) String() string { return proto.CompactTextString(m) }
+func (*ClusterState) ProtoMessage()    {}
+func (*ClusterState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{8}
+}
+
+func (m *ClusterState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterState.Unmarshal(m, b)
+}
+func (m *ClusterState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterState.Marshal(b, m, deterministic)
+}
+func (m *ClusterState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterState.Merge(m, src)
+}
+func (m *ClusterState) XXX_Size() int {
+	return xxx_messageInfo_ClusterState.Size(m)
+}
+func (m *ClusterState) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClusterState proto.InternalMessageInfo
+
+func (m *ClusterState) GetNodes() []*NodeState {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *ClusterState) GetPools() []*PoolState {
+	if m != nil {
+		return m.Pools
+	}
+	return nil
+}
+
+func (m *ClusterState) GetTopNamespaces() []*NamespaceUsage {
+	if m != nil {
+		return m.TopNamespaces
+	}
+	return nil
+}
+
+type Promise struct {
+	PodUid               string               `protobuf:"bytes,1,opt,name=pod_uid,json=podUid,proto3" json:"pod_uid,omitempty"`
+	Count                int64                `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Made                 *timestamp.Timestamp `protobuf:"bytes,3,opt,name=made,proto3" json:"made,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Promise) Reset()         { *m = Promise{} }
+func (m *Promise) String() string { return proto.CompactTextString(m) }
+func (*Promise) ProtoMessage()    {}
+func (*Promise) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{9}
+}
+
+func (m *Promise) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Promise.Unmarshal(m, b)
+}
+func (m *Promise) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Promise.Marshal(b, m, deterministic)
+}
+func (m *Promise) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Promise.Merge(m, src)
+}
+func (m *Promise) XXX_Size() int {
+	return xxx_messageInfo_Promise.Size(m)
+}
+func (m *Promise) XXX_DiscardUnknown() {
+	xxx_messageInfo_Promise.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Promise proto.InternalMessageInfo
+
+func (m *Promise) GetPodUid() string {
+	if m != nil {
+		return m.PodUid
+	}
+	return ""
+}
+
+func (m *Promise) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *Promise) GetMade() *timestamp.Timestamp {
+	if m != nil {
+		return m.Made
+	}
+	return nil
+}
+
+type ListPromisesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListPromisesRequest) Reset()         { *m = ListPromisesRequest{} }
+func (m *ListPromisesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListPromisesRequest) ProtoMessage()    {}
+func (*ListPromisesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{10}
+}
+
+func (m *ListPromisesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPromisesRequest.Unmarshal(m, b)
+}
+func (m *ListPromisesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPromisesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListPromisesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPromisesRequest.Merge(m, src)
+}
+func (m *ListPromisesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListPromisesRequest.Size(m)
+}
+func (m *ListPromisesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPromisesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPromisesRequest proto.InternalMessageInfo
+
+type ListPromisesResponse struct {
+	Promises             []*Promise `protobuf:"bytes,1,rep,name=promises,proto3" json:"promises,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *ListPromisesResponse) Reset()         { *m = ListPromisesResponse{} }
+func (m *ListPromisesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListPromisesResponse) ProtoMessage()    {}
+func (*ListPromisesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{11}
+}
+
+func (m *ListPromisesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPromisesResponse.Unmarshal(m, b)
+}
+func (m *ListPromisesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPromisesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListPromisesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPromisesResponse.Merge(m, src)
+}
+func (m *ListPromisesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListPromisesResponse.Size(m)
+}
+func (m *ListPromisesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPromisesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPromisesResponse proto.InternalMessageInfo
+
+func (m *ListPromisesResponse) GetPromises() []*Promise {
+	if m != nil {
+		return m.Promises
+	}
+	return nil
+}
+
+type MakePromiseRequest struct {
+	PodUid string `protobuf:"bytes,1,opt,name=pod_uid,json=podUid,proto3" json:"pod_uid,omitempty"`
+	Count  int64  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	// Namespace and name of the pod, it has to exist and have pod_uid.
+	PodNamespace         string   `protobuf:"bytes,3,opt,name=pod_namespace,json=podNamespace,proto3" json:"pod_namespace,omitempty"`
+	PodName              string   `protobuf:"bytes,4,opt,name=pod_name,json=podName,proto3" json:"pod_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MakePromiseRequest) Reset()         { *m = MakePromiseRequest{} }
+func (m *MakePromiseRequest) String() string { return proto.CompactTextString(m) }
+func (*MakePromiseRequest) ProtoMessage()    {}
+func (*MakePromiseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{12}
+}
+
+func (m *MakePromiseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MakePromiseRequest.Unmarshal(m, b)
+}
+func (m *MakePromiseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MakePromiseRequest.Marshal(b, m, deterministic)
+}
+func (m *MakePromiseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MakePromiseRequest.Merge(m, src)
+}
+func (m *MakePromiseRequest) XXX_Size() int {
+	return xxx_messageInfo_MakePromiseRequest.Size(m)
+}
+func (m *MakePromiseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MakePromiseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MakePromiseRequest proto.InternalMessageInfo
+
+func (m *MakePromiseRequest) GetPodUid() string {
+	if m != nil {
+		return m.PodUid
+	}
+	return ""
+}
+
+func (m *MakePromiseRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *MakePromiseRequest) GetPodNamespace() string {
+	if m != nil {
+		return m.PodNamespace
+	}
+	return ""
+}
+
+func (m *MakePromiseRequest) GetPodName() string {
+	if m != nil {
+		return m.PodName
+	}
+	return ""
+}
+
+type PurgePromiseRequest struct {
+	PodUid               string   `protobuf:"bytes,1,opt,name=pod_uid,json=podUid,proto3" json:"pod_uid,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PurgePromiseRequest) Reset()         { *m = PurgePromiseRequest{} }
+func (m *PurgePromiseRequest) String() string { return proto.CompactTextString(m) }
+func (*PurgePromiseRequest) ProtoMessage()    {}
+func (*PurgePromiseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{13}
+}
+
+func (m *PurgePromiseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PurgePromiseRequest.Unmarshal(m, b)
+}
+func (m *PurgePromiseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PurgePromiseRequest.Marshal(b, m, deterministic)
+}
+func (m *PurgePromiseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PurgePromiseRequest.Merge(m, src)
+}
+func (m *PurgePromiseRequest) XXX_Size() int {
+	return xxx_messageInfo_PurgePromiseRequest.Size(m)
+}
+func (m *PurgePromiseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PurgePromiseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PurgePromiseRequest proto.InternalMessageInfo
+
+func (m *PurgePromiseRequest) GetPodUid() string {
+	if m != nil {
+		return m.PodUid
+	}
+	return ""
+}
+
+type PurgePromiseResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PurgePromiseResponse) Reset()         { *m = PurgePromiseResponse{} }
+func (m *PurgePromiseResponse) String() string { return proto.CompactTextString(m) }
+func (*PurgePromiseResponse) ProtoMessage()    {}
+func (*PurgePromiseResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ae44814bab192c0f, []int{14}
+}
+
+func (m *PurgePromiseResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PurgePromiseResponse.Unmarshal(m, b)
+}
+func (m *PurgePromiseResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PurgePromiseResponse.Marshal(b, m, deterministic)
+}
+func (m *PurgePromiseResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PurgePromiseResponse.Merge(m, src)
+}
+func (m *PurgePromiseResponse) XXX_Size() int {
+	return xxx_messageInfo_PurgePromiseResponse.Size(m)
+}
+func (m *PurgePromiseResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PurgePromiseResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PurgePromiseResponse proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*ExtenderArgs)(nil), "sriov.extender.v1.ExtenderArgs")
+	proto.RegisterType((*FilterResult)(nil), "sriov.extender.v1.FilterResult")
+	proto.RegisterMapType((map[string]string)(nil), "sriov.extender.v1.FilterResult.FailedAndUnresolvableNodesEntry")
+	proto.RegisterMapType((map[string]string)(nil), "sriov.extender.v1.FilterResult.FailedNodesEntry")
+	proto.RegisterType((*HostPriority)(nil), "sriov.extender.v1.HostPriority")
+	proto.RegisterType((*PrioritizeResult)(nil), "sriov.extender.v1.PrioritizeResult")
+	proto.RegisterType((*GetStateRequest)(nil), "sriov.extender.v1.GetStateRequest")
+	proto.RegisterType((*PoolState)(nil), "sriov.extender.v1.PoolState")
+	proto.RegisterType((*NodeState)(nil), "sriov.extender.v1.NodeState")
+	proto.RegisterType((*NamespaceUsage)(nil), "sriov.extender.v1.NamespaceUsage")
+	proto.RegisterType((*ClusterState)(nil), "sriov.extender.v1.ClusterState")
+	proto.RegisterType((*Promise)(nil), "sriov.extender.v1.Promise")
+	proto.RegisterType((*ListPromisesRequest)(nil), "sriov.extender.v1.ListPromisesRequest")
+	proto.RegisterType((*ListPromisesResponse)(nil), "sriov.extender.v1.ListPromisesResponse")
+	proto.RegisterType((*MakePromiseRequest)(nil), "sriov.extender.v1.MakePromiseRequest")
+	proto.RegisterType((*PurgePromiseRequest)(nil), "sriov.extender.v1.PurgePromiseRequest")
+	proto.RegisterType((*PurgePromiseResponse)(nil), "sriov.extender.v1.PurgePromiseResponse")
+}
+
+func init() { proto.RegisterFile("pkg/extenderpb/extender.proto", fileDescriptor_ae44814bab192c0f) }
+
+var fileDescriptor_ae44814bab192c0f = []byte{
+	// 857 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0xef, 0x8e, 0xdb, 0x44,
+	0x10, 0x97, 0xcf, 0xf7, 0x2f, 0x13, 0xb7, 0x5c, 0xb7, 0x07, 0x18, 0xab, 0xa7, 0x04, 0x57, 0xd0,
+	0x7c, 0xc1, 0x81, 0x20, 0xa1, 0x0a, 0x04, 0xa7, 0x82, 0x5a, 0x2a, 0xd4, 0x3b, 0x22, 0x87, 0x7c,
+	0xe1, 0x4b, 0xe4, 0xc4, 0x93, 0xc4, 0x3a, 0xc7, 0x6b, 0x76, 0xd7, 0x11, 0xc7, 0x47, 0x78, 0x00,
+	0x1e, 0x81, 0x17, 0xe0, 0x21, 0x78, 0x34, 0xb4, 0xbb, 0x5e, 0xc7, 0x49, 0x5d, 0xd2, 0xe3, 0xdb,
+	0xcc, 0x78, 0x7e, 0x3b, 0xbf, 0x99, 0xfd, 0xed, 0x24, 0x70, 0x91, 0xdf, 0x2c, 0xfa, 0xf8, 0xab,
+	0xc0, 0x2c, 0x46, 0x96, 0x4f, 0x2b, 0x33, 0xc8, 0x19, 0x15, 0x94, 0x3c, 0xe0, 0x2c, 0xa1, 0xeb,
+	0xa0, 0x8a, 0xae, 0x3f, 0xf3, 0x3a, 0x0b, 0x4a, 0x17, 0x29, 0xf6, 0x55, 0xc2, 0xb4, 0x98, 0xf7,
+	0x45, 0xb2, 0x42, 0x2e, 0xa2, 0x55, 0xae, 0x31, 0xfe, 0x18, 0x9c, 0xe7, 0x65, 0xfe, 0x33, 0xb6,
+	0xe0, 0xe4, 0x0c, 0xec, 0x9c, 0xc6, 0xae, 0xd5, 0xb5, 0x7a, 0x4e, 0x28, 0x4d, 0x72, 0x0e, 0x47,
+	0x19, 0x8d, 0x91, 0xbb, 0x07, 0x5d, 0xbb, 0xe7, 0x84, 0xda, 0x21, 0x17, 0x00, 0xd2, 0x98, 0x64,
+	0xd1, 0x0a, 0xb9, 0x6b, 0x77, 0xed, 0x5e, 0x2b, 0x6c, 0xc9, 0xc8, 0xb5, 0x0c, 0xf8, 0x7f, 0xdb,
+	0xe0, 0xbc, 0x48, 0x52, 0x81, 0x2c, 0x44, 0x5e, 0xa4, 0x62, 0x27, 0xdf, 0xda, 0xc9, 0x27, 0x23,
+	0x70, 0xe6, 0x51, 0x92, 0x62, 0x3c, 0xd9, 0xd4, 0x6a, 0x0f, 0x3e, 0x0d, 0x5e, 0xeb, 0x28, 0xa8,
+	0x9f, 0x1a, 0xbc, 0x50, 0x98, 0x6b, 0x09, 0x79, 0x9e, 0x09, 0x76, 0x1b, 0xb6, 0xe7, 0x9b, 0x08,
+	0xf9, 0xdd, 0x82, 0x8b, 0xf2, 0xd4, 0x28, 0x8b, 0x27, 0x45, 0xc6, 0x90, 0xd3, 0x74, 0x1d, 0x4d,
+	0x53, 0x2c, 0xcb, 0xd8, 0xaa, 0xcc, 0xe5, 0xdb, 0x95, 0x79, 0x96, 0xc5, 0xe3, 0xda, 0x11, 0xb5,
+	0xaa, 0xde, 0xfc, 0x8d, 0x09, 0x72, 0x7c, 0xc8, 0x18, 0x65, 0xee, 0x61, 0xd7, 0xea, 0xb5, 0x42,
+	0xed, 0x78, 0xdf, 0xc0, 0xd9, 0x2e, 0x77, 0x39, 0xfa, 0x1b, 0xbc, 0x55, 0xa3, 0x6f, 0x85, 0xd2,
+	0x94, 0xd8, 0x75, 0x94, 0x16, 0xe8, 0x1e, 0x68, 0xac, 0x72, 0xbe, 0x3c, 0x78, 0x6a, 0x79, 0x57,
+	0xd0, 0xd9, 0x43, 0xea, 0x2e, 0xc7, 0xf9, 0x4f, 0xc1, 0x79, 0x49, 0xb9, 0x18, 0xb2, 0x84, 0xb2,
+	0x44, 0xdc, 0x12, 0x02, 0x87, 0x4b, 0xca, 0x45, 0x09, 0x56, 0xb6, 0x44, 0xf3, 0x19, 0x65, 0x1a,
+	0x6d, 0x87, 0xda, 0xf1, 0x47, 0x70, 0x56, 0xa2, 0x92, 0xdf, 0xb0, 0xbc, 0xeb, 0x4b, 0x80, 0xbc,
+	0x8c, 0x95, 0x77, 0xdd, 0x1e, 0x74, 0x1a, 0x66, 0x5c, 0x2f, 0x19, 0xd6, 0x20, 0xfe, 0x03, 0x78,
+	0xe7, 0x7b, 0x14, 0x23, 0x11, 0x09, 0x0c, 0xf1, 0x97, 0x02, 0xb9, 0xf0, 0xff, 0xb2, 0xa0, 0x35,
+	0xa4, 0x34, 0x55, 0x41, 0xc9, 0x2f, 0xa7, 0x34, 0x35, 0xfc, 0xa4, 0x2d, 0xf9, 0x09, 0x2a, 0xa2,
+	0xd4, 0xf0, 0x53, 0x0e, 0xf1, 0xe0, 0x94, 0x21, 0x47, 0xb6, 0xc6, 0xd8, 0xb5, 0xd5, 0x87, 0xca,
+	0x27, 0x8f, 0xa0, 0x15, 0xa5, 0x29, 0x9d, 0x45, 0x02, 0x63, 0x75, 0x3d, 0x76, 0xb8, 0x09, 0x48,
+	0x64, 0xce, 0xe8, 0x2a, 0xe1, 0x18, 0xbb, 0x47, 0x1a, 0x69, 0x7c, 0x59, 0x7f, 0xce, 0x10, 0xdd,
+	0x63, 0x15, 0x57, 0xb6, 0x3f, 0x82, 0x96, 0x9c, 0x7e, 0x45, 0x50, 0x2a, 0xcc, 0x10, 0x94, 0x36,
+	0x19, 0xc0, 0x91, 0x24, 0x6a, 0xc4, 0xfd, 0xa8, 0x61, 0x22, 0x55, 0x87, 0xa1, 0x4e, 0xf5, 0x5f,
+	0xc1, 0x7d, 0xf5, 0x40, 0xf2, 0x68, 0x86, 0x63, 0x1e, 0x2d, 0x50, 0x92, 0xce, 0x4c, 0xa4, 0x3c,
+	0x7e, 0x13, 0xd8, 0x6e, 0xe9, 0x60, 0xa7, 0x25, 0xff, 0x1f, 0x0b, 0x9c, 0xef, 0xd2, 0x82, 0x0b,
+	0x64, 0x9a, 0xe6, 0xc0, 0xbc, 0x6d, 0xeb, 0x8d, 0x94, 0xaa, 0x9e, 0xcc, 0xcb, 0xff, 0x1f, 0x6d,
+	0x90, 0x97, 0x70, 0x5f, 0xd0, 0x7c, 0x52, 0xf1, 0x34, 0x2f, 0xef, 0xc3, 0xa6, 0x82, 0x5b, 0xfd,
+	0x86, 0xf7, 0x04, 0xcd, 0xab, 0x10, 0xf7, 0x97, 0x70, 0x32, 0xd4, 0xb7, 0x40, 0xde, 0x87, 0x93,
+	0x9c, 0xc6, 0x93, 0x22, 0x89, 0xcb, 0x39, 0x1c, 0xe7, 0x34, 0x1e, 0x27, 0x6a, 0x63, 0xcd, 0x68,
+	0x91, 0x09, 0xa3, 0x04, 0xe5, 0x90, 0x00, 0x0e, 0x57, 0x51, 0x8c, 0x4a, 0x05, 0xed, 0x81, 0x17,
+	0xe8, 0xcd, 0x18, 0x98, 0xcd, 0x18, 0xfc, 0x64, 0x36, 0x63, 0xa8, 0xf2, 0xfc, 0x77, 0xe1, 0xe1,
+	0xab, 0x84, 0x8b, 0xb2, 0x1a, 0x37, 0x42, 0xbc, 0x86, 0xf3, 0xed, 0x30, 0xcf, 0x69, 0xc6, 0x91,
+	0x7c, 0x51, 0xc9, 0xc5, 0x4c, 0xd3, 0x6b, 0x9a, 0x8c, 0x4e, 0xa9, 0xa4, 0xc4, 0xfd, 0x3f, 0x2c,
+	0x20, 0x57, 0xd1, 0x0d, 0x9a, 0x2f, 0xba, 0xcc, 0x5d, 0x9b, 0x7b, 0x0c, 0xf7, 0x64, 0xfa, 0x46,
+	0x19, 0xb6, 0x02, 0x39, 0x39, 0x8d, 0xab, 0xe1, 0x91, 0x0f, 0xe0, 0xd4, 0x24, 0x95, 0xdb, 0xe8,
+	0xa4, 0xfc, 0xee, 0x07, 0xf0, 0x70, 0x58, 0xb0, 0xc5, 0xdb, 0xb2, 0xf0, 0xdf, 0x83, 0xf3, 0xed,
+	0x7c, 0x3d, 0x85, 0xc1, 0x9f, 0x87, 0x70, 0x6a, 0x7e, 0x4f, 0xc8, 0x0f, 0x70, 0xac, 0xd7, 0x28,
+	0x69, 0x7a, 0xfd, 0xf5, 0x9f, 0x1d, 0xaf, 0xb3, 0x67, 0x05, 0x93, 0x10, 0x60, 0xb3, 0x67, 0xf6,
+	0x9f, 0xf7, 0xb8, 0x71, 0xf6, 0x3b, 0x7b, 0xea, 0x47, 0x38, 0x35, 0x6b, 0x86, 0xf8, 0x0d, 0x80,
+	0x9d, 0x1d, 0xd4, 0x48, 0x72, 0xeb, 0x39, 0x4d, 0xc0, 0xa9, 0x6b, 0x83, 0x7c, 0xdc, 0x00, 0x68,
+	0xd0, 0x94, 0xf7, 0x64, 0x6f, 0x5e, 0x29, 0xb2, 0x21, 0xb4, 0x6b, 0x5a, 0x21, 0x1f, 0x35, 0xe0,
+	0x5e, 0xd7, 0x92, 0xf7, 0x1f, 0x42, 0x94, 0x94, 0xeb, 0x17, 0xd9, 0x48, 0xb9, 0x41, 0x19, 0xde,
+	0x93, 0xbd, 0x79, 0x9a, 0xf2, 0xb7, 0x97, 0x3f, 0x7f, 0xbd, 0x48, 0xc4, 0xb2, 0x98, 0x06, 0x33,
+	0xba, 0xea, 0x5f, 0x25, 0x2c, 0xca, 0x44, 0xc2, 0xfb, 0x0a, 0xfd, 0x09, 0x9f, 0x2d, 0x31, 0x2e,
+	0x52, 0x64, 0xfd, 0xed, 0x7f, 0x36, 0x5f, 0x6d, 0xcc, 0xe9, 0xb1, 0x7a, 0xa1, 0x9f, 0xff, 0x3b,
+	0x00, 0x32, 0x7a, 0x22, 0xe0, 0xfd, 0x08, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ExtenderClient is the client API for Extender service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ExtenderClient interface {
+	// Filter returns nodes with enough VFs for a pod and promises VFs to the pod.
+	Filter(ctx context.Context, in *ExtenderArgs, opts ...grpc.CallOption) (*FilterResult, error)
+	// Prioritize scores nodes by a number of VFs left for a pod.
+	Prioritize(ctx context.Context, in *ExtenderArgs, opts ...grpc.CallOption) (*PrioritizeResult, error)
+	// GetState reports allocated, promised and free VFs of every node and pool.
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*ClusterState, error)
+	// ListPromises returns VFs promised to pods that are not bound yet.
+	ListPromises(ctx context.Context, in *ListPromisesRequest, opts ...grpc.CallOption) (*ListPromisesResponse, error)
+	// MakePromise reserves VFs for a pod until it is bound or the promise is purged.
+	MakePromise(ctx context.Context, in *MakePromiseRequest, opts ...grpc.CallOption) (*Promise, error)
+	// PurgePromise releases VFs promised to a pod.
+	PurgePromise(ctx context.Context, in *PurgePromiseRequest, opts ...grpc.CallOption) (*PurgePromiseResponse, error)
+}
+
+type extenderClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewExtenderClient(cc *grpc.ClientConn) ExtenderClient {
+	return &extenderClient{cc}
+}
+
+func (c *extenderClient) Filter(ctx context.Context, in *ExtenderArgs, opts ...grpc.CallOption) (*FilterResult, error) {
+	out := new(FilterResult)
+	err := c.cc.Invoke(ctx, "/sriov.extender.v1.Extender/Filter", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extenderClient) Prioritize(ctx context.Context, in *ExtenderArgs, opts ...grpc.CallOption) (*PrioritizeResult, error) {
+	out := new(PrioritizeResult)
+	err := c.cc.Invoke(ctx, "/sriov.extender.v1.Extender/Prioritize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extenderClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*ClusterState, error) {
+	out := new(ClusterState)
+	err := c.cc.Invoke(ctx, "/sriov.extender.v1.Extender/GetState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extenderClient) ListPromises(ctx context.Context, in *ListPromisesRequest, opts ...grpc.CallOption) (*ListPromisesResponse, error) {
+	out := new(ListPromisesResponse)
+	err := c.cc.Invoke(ctx, "/sriov.extender.v1.Extender/ListPromises", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extenderClient) MakePromise(ctx context.Context, in *MakePromiseRequest, opts ...grpc.CallOption) (*Promise, error) {
+	out := new(Promise)
+	err := c.cc.Invoke(ctx, "/sriov.extender.v1.Extender/MakePromise", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extenderClient) PurgePromise(ctx context.Context, in *PurgePromiseRequest, opts ...grpc.CallOption) (*PurgePromiseResponse, error) {
+	out := new(PurgePromiseResponse)
+	err := c.cc.Invoke(ctx, "/sriov.extender.v1.Extender/PurgePromise", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExtenderServer is the server API for Extender service.
+type ExtenderServer interface {
+	// Filter returns nodes with enough VFs for a pod and promises VFs to the pod.
+	Filter(context.Context, *ExtenderArgs) (*FilterResult, error)
+	// Prioritize scores nodes by a number of VFs left for a pod.
+	Prioritize(context.Context, *ExtenderArgs) (*PrioritizeResult, error)
+	// GetState reports allocated, promised and free VFs of every node and pool.
+	GetState(context.Context, *GetStateRequest) (*ClusterState, error)
+	// ListPromises returns VFs promised to pods that are not bound yet.
+	ListPromises(context.Context, *ListPromisesRequest) (*ListPromisesResponse, error)
+	// MakePromise reserves VFs for a pod until it is bound or the promise is purged.
+	MakePromise(context.Context, *MakePromiseRequest) (*Promise, error)
+	// PurgePromise releases VFs promised to a pod.
+	PurgePromise(context.Context, *PurgePromiseRequest) (*PurgePromiseResponse, error)
+}
+
+// UnimplementedExtenderServer can be embedded to have forward compatible implementations.
+type UnimplementedExtenderServer struct {
+}
+
+func (*UnimplementedExtenderServer) Filter(ctx context.Context, req *ExtenderArgs) (*FilterResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Filter not implemented")
+}
+func (*UnimplementedExtenderServer) Prioritize(ctx context.Context, req *ExtenderArgs) (*PrioritizeResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Prioritize not implemented")
+}
+func (*UnimplementedExtenderServer) GetState(ctx context.Context, req *GetStateRequest) (*ClusterState, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (*UnimplementedExtenderServer) ListPromises(ctx context.Context, req *ListPromisesRequest) (*ListPromisesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPromises not implemented")
+}
+func (*UnimplementedExtenderServer) MakePromise(ctx context.Context, req *MakePromiseRequest) (*Promise, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MakePromise not implemented")
+}
+func (*UnimplementedExtenderServer) PurgePromise(ctx context.Context, req *PurgePromiseRequest) (*PurgePromiseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgePromise not implemented")
+}
+
+func RegisterExtenderServer(s *grpc.Server, srv ExtenderServer) {
+	s.RegisterService(&_Extender_serviceDesc, srv)
+}
+
+func _Extender_Filter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtenderArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtenderServer).Filter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sriov.extender.v1.Extender/Filter",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtenderServer).Filter(ctx, req.(*ExtenderArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Extender_Prioritize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtenderArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtenderServer).Prioritize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sriov.extender.v1.Extender/Prioritize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtenderServer).Prioritize(ctx, req.(*ExtenderArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Extender_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtenderServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sriov.extender.v1.Extender/GetState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtenderServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Extender_ListPromises_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPromisesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtenderServer).ListPromises(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sriov.extender.v1.Extender/ListPromises",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtenderServer).ListPromises(ctx, req.(*ListPromisesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Extender_MakePromise_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MakePromiseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtenderServer).MakePromise(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sriov.extender.v1.Extender/MakePromise",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtenderServer).MakePromise(ctx, req.(*MakePromiseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Extender_PurgePromise_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgePromiseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtenderServer).PurgePromise(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sriov.extender.v1.Extender/PurgePromise",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtenderServer).PurgePromise(ctx, req.(*PurgePromiseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Extender_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "sriov.extender.v1.Extender",
+	HandlerType: (*ExtenderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Filter",
+			Handler:    _Extender_Filter_Handler,
+		},
+		{
+			MethodName: "Prioritize",
+			Handler:    _Extender_Prioritize_Handler,
+		},
+		{
+			MethodName: "GetState",
+			Handler:    _Extender_GetState_Handler,
+		},
+		{
+			MethodName: "ListPromises",
+			Handler:    _Extender_ListPromises_Handler,
+		},
+		{
+			MethodName: "MakePromise",
+			Handler:    _Extender_MakePromise_Handler,
+		},
+		{
+			MethodName: "PurgePromise",
+			Handler:    _Extender_PurgePromise_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/extenderpb/extender.proto",
+}
@@ -0,0 +1,140 @@
+package nodestate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// Interface manages SriovNodeStates.
+type Interface interface {
+	Get(name string) (*SriovNodeState, error)
+	List(options meta_v1.ListOptions) (*SriovNodeStateList, error)
+	Watch(options meta_v1.ListOptions) (watch.Interface, error)
+	Create(state *SriovNodeState) (*SriovNodeState, error)
+	Update(state *SriovNodeState) (*SriovNodeState, error)
+	Delete(name string) error
+}
+
+// client converts SriovNodeStates to and from unstructured objects served by the dynamic client.
+type client struct {
+	resource *dynamic.ResourceClient
+}
+
+// NewForConfig creates a client of SriovNodeStates, custom resource definition has to be created
+// separately, see tools/sriovnodestate.yaml.
+func NewForConfig(config *rest.Config) (Interface, error) {
+	stateConfig := *config
+	stateConfig.APIPath = "/apis"
+	stateConfig.GroupVersion = &SchemeGroupVersion
+	dynamicClient, err := dynamic.NewClient(&stateConfig)
+	if err != nil {
+		return nil, err
+	}
+	resource := dynamicClient.Resource(&meta_v1.APIResource{Name: Resource, Namespaced: false}, "")
+	return &client{resource: resource}, nil
+}
+
+func (c *client) Get(name string) (*SriovNodeState, error) {
+	obj, err := c.resource.Get(name, meta_v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromUnstructured(obj)
+}
+
+func (c *client) List(options meta_v1.ListOptions) (*SriovNodeStateList, error) {
+	obj, err := c.resource.List(options)
+	if err != nil {
+		return nil, err
+	}
+	list := &SriovNodeStateList{}
+	if err := convert(obj, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (c *client) Watch(options meta_v1.ListOptions) (watch.Interface, error) {
+	w, err := c.resource.Watch(options)
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+		obj, ok := event.Object.(*unstructured.Unstructured)
+		if !ok {
+			// errors are passed as metav1.Status
+			return event, true
+		}
+		state, err := fromUnstructured(obj)
+		if err != nil {
+			return watch.Event{Type: watch.Error, Object: &meta_v1.Status{
+				Status: meta_v1.StatusFailure, Message: err.Error()}}, true
+		}
+		event.Object = state
+		return event, true
+	}), nil
+}
+
+func (c *client) Create(state *SriovNodeState) (*SriovNodeState, error) {
+	obj, err := toUnstructured(state)
+	if err != nil {
+		return nil, err
+	}
+	if obj, err = c.resource.Create(obj); err != nil {
+		return nil, err
+	}
+	return fromUnstructured(obj)
+}
+
+func (c *client) Update(state *SriovNodeState) (*SriovNodeState, error) {
+	obj, err := toUnstructured(state)
+	if err != nil {
+		return nil, err
+	}
+	if obj, err = c.resource.Update(obj); err != nil {
+		return nil, err
+	}
+	return fromUnstructured(obj)
+}
+
+func (c *client) Delete(name string) error {
+	return c.resource.Delete(name, &meta_v1.DeleteOptions{})
+}
+
+func toUnstructured(state *SriovNodeState) (*unstructured.Unstructured, error) {
+	withKind := *state
+	withKind.APIVersion = SchemeGroupVersion.String()
+	withKind.Kind = Kind
+	obj := &unstructured.Unstructured{}
+	if err := convert(&withKind, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func fromUnstructured(obj *unstructured.Unstructured) (*SriovNodeState, error) {
+	state := &SriovNodeState{}
+	if err := convert(obj, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// convert copies an object to another type through JSON.
+func convert(from runtime.Object, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %v", Kind, err)
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		return fmt.Errorf("error decoding %s: %v", Kind, err)
+	}
+	return nil
+}
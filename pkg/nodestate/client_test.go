@@ -0,0 +1,92 @@
+package nodestate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+const statesPath = "/apis/sriov.mirantis.com/v1alpha1/sriovnodestates"
+
+func makeTestState(name string) SriovNodeState {
+	state := SriovNodeState{Status: SriovNodeStateStatus{PFs: []PF{{
+		Name: "ens1f0", PCIAddress: "0000:03:00.0", Vendor: "8086", Device: "1572", Driver: "i40e",
+		NUMANode: 1, TotalVFs: 8, NumVFs: 2,
+		VFs: []VF{
+			{Index: 0, PCIAddress: "0000:03:02.0", Driver: "iavf", Mode: "netdev", Netdev: "ens1f0v0",
+				MAC: "52:54:00:00:00:01", VLAN: 100, InUse: true},
+			{Index: 1, PCIAddress: "0000:03:02.1", Driver: "vfio-pci", Mode: "dpdk"},
+		},
+	}}}}
+	state.Name = name
+	return state
+}
+
+func TestClient(t *testing.T) {
+	var requests []string
+	var created map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == statesPath:
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(body, &created))
+			created["metadata"].(map[string]interface{})["resourceVersion"] = "1"
+			w.WriteHeader(http.StatusCreated)
+			require.NoError(t, json.NewEncoder(w).Encode(created))
+		case r.Method == http.MethodGet && r.URL.Path == statesPath+"/node-1":
+			require.NoError(t, json.NewEncoder(w).Encode(created))
+		case r.Method == http.MethodGet && r.URL.Path == statesPath:
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"apiVersion": "sriov.mirantis.com/v1alpha1",
+				"kind":       "SriovNodeStateList",
+				"metadata":   map[string]interface{}{"resourceVersion": "1"},
+				"items":      []interface{}{created},
+			}))
+		case r.Method == http.MethodDelete && r.URL.Path == statesPath+"/node-1":
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"apiVersion": "v1", "kind": "Status", "status": "Success"}))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewForConfig(&rest.Config{Host: srv.URL})
+	require.NoError(t, err)
+	state := makeTestState("node-1")
+	stored, err := client.Create(&state)
+	require.NoError(t, err)
+	require.Equal(t, "sriov.mirantis.com/v1alpha1", created["apiVersion"])
+	require.Equal(t, Kind, created["kind"])
+	require.Equal(t, "1", stored.ResourceVersion)
+	require.Equal(t, state.Status, stored.Status)
+
+	fetched, err := client.Get("node-1")
+	require.NoError(t, err)
+	require.Equal(t, stored, fetched)
+
+	list, err := client.List(meta_v1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, *stored, list.Items[0])
+
+	require.NoError(t, client.Delete("node-1"))
+	_, err = client.Get("node-2")
+	require.Error(t, err)
+	require.Equal(t, []string{
+		"POST " + statesPath,
+		"GET " + statesPath + "/node-1",
+		"GET " + statesPath,
+		"DELETE " + statesPath + "/node-1",
+		"GET " + statesPath + "/node-2",
+	}, requests)
+}
@@ -0,0 +1,16 @@
+package nodestate
+
+import (
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+const (
+	// PFLinkDownCondition of a node is set by discovery, it is true while a link of any PF is down.
+	PFLinkDownCondition v1.NodeConditionType = "SriovPFLinkDown"
+	// PFLinksUpReason of PFLinkDownCondition means that links of all PFs are up.
+	PFLinksUpReason = "PFLinksUp"
+	// PFLinkDownReason of PFLinkDownCondition means that links of some PFs are down.
+	PFLinkDownReason = "PFLinkDown"
+	// AllPFLinksDownReason of PFLinkDownCondition means that none of PFs can carry traffic of VFs.
+	AllPFLinksDownReason = "AllPFLinksDown"
+)
@@ -0,0 +1,120 @@
+package nodestate
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var groupResource = schema.GroupResource{Group: GroupName, Resource: Resource}
+
+// fakeWatchEvents is a number of events buffered by a fake watcher.
+const fakeWatchEvents = 100
+
+// Fake keeps SriovNodeStates in memory, it is meant for tests of components using Interface.
+// Update fails with a conflict if resource version doesn't match, same as the API server.
+type Fake struct {
+	sync.Mutex
+	states  map[string]SriovNodeState
+	version int
+	// watcher is the last one returned by Watch
+	watcher *watch.FakeWatcher
+}
+
+// NewFake creates a fake client with some states.
+func NewFake(states ...SriovNodeState) *Fake {
+	f := &Fake{states: map[string]SriovNodeState{}}
+	for _, state := range states {
+		f.version++
+		state.ResourceVersion = fmt.Sprint(f.version)
+		f.states[state.Name] = *state.DeepCopy()
+	}
+	return f
+}
+
+func (f *Fake) Get(name string) (*SriovNodeState, error) {
+	f.Lock()
+	defer f.Unlock()
+	state, exists := f.states[name]
+	if !exists {
+		return nil, errors.NewNotFound(groupResource, name)
+	}
+	return state.DeepCopy(), nil
+}
+
+func (f *Fake) List(options meta_v1.ListOptions) (*SriovNodeStateList, error) {
+	f.Lock()
+	defer f.Unlock()
+	list := &SriovNodeStateList{Items: []SriovNodeState{}}
+	list.ResourceVersion = fmt.Sprint(f.version)
+	for _, state := range f.states {
+		list.Items = append(list.Items, *state.DeepCopy())
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	return list, nil
+}
+
+// Watch returns events of changes made after it was called, events are sent only to the last watcher.
+func (f *Fake) Watch(options meta_v1.ListOptions) (watch.Interface, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.watcher = watch.NewFakeWithChanSize(fakeWatchEvents, false)
+	return f.watcher, nil
+}
+
+func (f *Fake) Create(state *SriovNodeState) (*SriovNodeState, error) {
+	f.Lock()
+	defer f.Unlock()
+	if _, exists := f.states[state.Name]; exists {
+		return nil, errors.NewAlreadyExists(groupResource, state.Name)
+	}
+	return f.store(watch.Added, *state.DeepCopy()), nil
+}
+
+func (f *Fake) Update(state *SriovNodeState) (*SriovNodeState, error) {
+	f.Lock()
+	defer f.Unlock()
+	current, exists := f.states[state.Name]
+	if !exists {
+		return nil, errors.NewNotFound(groupResource, state.Name)
+	}
+	if state.ResourceVersion != current.ResourceVersion {
+		return nil, errors.NewConflict(groupResource, state.Name,
+			fmt.Errorf("resource version %s doesn't match %s", state.ResourceVersion, current.ResourceVersion))
+	}
+	return f.store(watch.Modified, *state.DeepCopy()), nil
+}
+
+func (f *Fake) Delete(name string) error {
+	f.Lock()
+	defer f.Unlock()
+	state, exists := f.states[name]
+	if !exists {
+		return errors.NewNotFound(groupResource, name)
+	}
+	delete(f.states, name)
+	f.notify(watch.Deleted, &state)
+	return nil
+}
+
+// store saves a state with the next resource version, must be called with the lock held.
+func (f *Fake) store(eventType watch.EventType, state SriovNodeState) *SriovNodeState {
+	f.version++
+	state.ResourceVersion = fmt.Sprint(f.version)
+	f.states[state.Name] = state
+	f.notify(eventType, state.DeepCopy())
+	return state.DeepCopy()
+}
+
+func (f *Fake) notify(eventType watch.EventType, state *SriovNodeState) {
+	if f.watcher != nil && !f.watcher.IsStopped() {
+		f.watcher.Action(eventType, state)
+	}
+}
@@ -0,0 +1,54 @@
+package nodestate
+
+import (
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+const informerResyncPeriod = 30 * time.Second
+
+// Informer keeps SriovNodeStates of all nodes cached.
+type Informer struct {
+	store      cache.Store
+	controller cache.Controller
+}
+
+// NewInformer creates informer which lists and watches SriovNodeStates with a client.
+func NewInformer(client Interface) *Informer {
+	lw := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return client.List(options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return client.Watch(options)
+		},
+	}
+	return NewInformerFromSource(lw)
+}
+
+// NewInformerFromSource creates informer with SriovNodeStates from any source, e.g. a fake one in tests.
+func NewInformerFromSource(lw cache.ListerWatcher) *Informer {
+	store, controller := cache.NewInformer(lw, &SriovNodeState{}, informerResyncPeriod, cache.ResourceEventHandlerFuncs{})
+	return &Informer{store: store, controller: controller}
+}
+
+func (i *Informer) Run(stopCh <-chan struct{}) {
+	i.controller.Run(stopCh)
+}
+
+func (i *Informer) HasSynced() bool {
+	return i.controller.HasSynced()
+}
+
+// Get returns state of a node and false if discovery didn't write it.
+func (i *Informer) Get(node string) (*SriovNodeState, bool, error) {
+	obj, exists, err := i.store.GetByKey(node)
+	if err != nil || !exists {
+		return nil, exists, err
+	}
+	return obj.(*SriovNodeState), true, nil
+}
@@ -0,0 +1,56 @@
+package nodestate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestInformer(t *testing.T) {
+	client := NewFake(makeTestState("node-1"))
+	informer := NewInformer(client)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+	require.NoError(t, wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		return informer.HasSynced(), nil
+	}))
+	state, exists, err := informer.Get("node-1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, "ens1f0", state.Status.PFs[0].Name)
+
+	created := makeTestState("node-2")
+	_, err = client.Create(&created)
+	require.NoError(t, err)
+	require.NoError(t, client.Delete("node-1"))
+	require.NoError(t, wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		_, exists, err := informer.Get("node-2")
+		return exists, err
+	}))
+	require.NoError(t, wait.PollImmediate(10*time.Millisecond, time.Second, func() (bool, error) {
+		_, exists, err := informer.Get("node-1")
+		return !exists, err
+	}))
+}
+
+func TestFakeUpdateConflicts(t *testing.T) {
+	client := NewFake(makeTestState("node-1"))
+	state, err := client.Get("node-1")
+	require.NoError(t, err)
+	state.Status.PFs = nil
+	updated, err := client.Update(state)
+	require.NoError(t, err)
+	_, err = client.Update(state)
+	require.True(t, errors.IsConflict(err))
+
+	fetched, err := client.Get("node-1")
+	require.NoError(t, err)
+	require.Equal(t, updated, fetched)
+	_, err = client.Create(fetched)
+	require.True(t, errors.IsAlreadyExists(err))
+	require.True(t, errors.IsNotFound(client.Delete("node-2")))
+}
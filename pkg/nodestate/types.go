@@ -0,0 +1,106 @@
+// Package nodestate contains SriovNodeState custom resource, an inventory of SR-IOV devices
+// of a node written by discovery, along with a client and an informer for it.
+package nodestate
+
+import (
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	GroupName = "sriov.mirantis.com"
+	Version   = "v1alpha1"
+	Kind      = "SriovNodeState"
+	// Resource is a plural name of SriovNodeState, the resource is cluster scoped.
+	Resource = "sriovnodestates"
+
+	// NUMANodeUnknown is a NUMA node of devices on hosts without NUMA.
+	NUMANodeUnknown = -1
+
+	// AllocationAnnotation records VFs the extender accounted against a node of a pod,
+	// discovery doesn't reconfigure PFs with VFs allocated to pods.
+	AllocationAnnotation = "vfallocation"
+)
+
+// SchemeGroupVersion is group and version of SriovNodeState.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: Version}
+
+// SriovNodeState is an inventory of SR-IOV devices of a node, it has the same name as the node.
+type SriovNodeState struct {
+	meta_v1.TypeMeta   `json:",inline"`
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status SriovNodeStateStatus `json:"status"`
+}
+
+// SriovNodeStateStatus is a state of devices discovered on a node.
+type SriovNodeStateStatus struct {
+	PFs []PF `json:"pfs"`
+}
+
+// PF is a physical function capable of SR-IOV.
+type PF struct {
+	// Name of the PF interface, e.g. ens1f0
+	Name string `json:"name"`
+	// PCIAddress such as 0000:03:00.0
+	PCIAddress string `json:"pciAddress,omitempty"`
+	// Vendor and Device are PCI IDs without 0x prefix, e.g. 8086 and 1572
+	Vendor string `json:"vendor,omitempty"`
+	Device string `json:"device,omitempty"`
+	Driver string `json:"driver,omitempty"`
+	// NUMANode the PF is attached to, NUMANodeUnknown if host doesn't report it
+	NUMANode int `json:"numaNode"`
+	// LinkDown is true if link of the PF can't carry traffic of its VFs
+	LinkDown bool  `json:"linkDown,omitempty"`
+	TotalVFs int64 `json:"totalVFs"`
+	NumVFs   int64 `json:"numVFs"`
+	VFs      []VF  `json:"vfs"`
+}
+
+// VF is a virtual function of a PF.
+type VF struct {
+	// Index of the VF on its PF, same as N of virtfnN
+	Index      int    `json:"index"`
+	PCIAddress string `json:"pciAddress,omitempty"`
+	// Driver VF is bound to, empty if none
+	Driver string `json:"driver,omitempty"`
+	// Mode of the driver, netdev or dpdk
+	Mode string `json:"mode,omitempty"`
+	// Netdev is a name of the VF interface while it is in the host network namespace
+	Netdev string `json:"netdev,omitempty"`
+	MAC    string `json:"mac,omitempty"`
+	VLAN   int    `json:"vlan,omitempty"`
+	// InUse is true if VF is claimed by the host, i.e. its interface is up in the host namespace
+	InUse bool `json:"inUse"`
+}
+
+// Usable returns true if VF can be given to a pod.
+func (vf VF) Usable() bool {
+	return vf.Driver != "" && !vf.InUse
+}
+
+// SriovNodeStateList is a list of SriovNodeStates.
+type SriovNodeStateList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata,omitempty"`
+
+	Items []SriovNodeState `json:"items"`
+}
+
+// DeepCopy returns a copy of the state which doesn't share PFs and VFs with it.
+func (s *SriovNodeState) DeepCopy() *SriovNodeState {
+	copied := *s
+	// generated copy of metadata fails only on types unknown to the cloner
+	_ = meta_v1.DeepCopy_v1_ObjectMeta(&s.ObjectMeta, &copied.ObjectMeta, conversion.NewCloner())
+	if s.Status.PFs != nil {
+		copied.Status.PFs = make([]PF, len(s.Status.PFs))
+		for i, pf := range s.Status.PFs {
+			if pf.VFs != nil {
+				pf.VFs = append([]VF{}, pf.VFs...)
+			}
+			copied.Status.PFs[i] = pf
+		}
+	}
+	return &copied
+}
@@ -46,7 +46,7 @@ func init() {
 
 // TestSriovExtender runs next scenario:
 // 1. Read discovery and extender definitions from this repository tools directory
-// 2. Patch discovery daemonset with fake sriov_totalvfs mount
+// 2. Patch discovery daemonset with fake sysfs of a device with configured VFs
 // 3. Deploy discovery daemonset and wait until totalvfs resource will be saved on nodes
 // 4. Deployment extender deployment and service. Wait until extender pods are ready.
 // 5. Update policy config for scheduler.
@@ -74,29 +74,34 @@ func TestSriovExtender(t *testing.T) {
 
 	sriovTotalVFsQuantity, err := resource.ParseQuantity(sriovTotalVFs)
 	require.NoError(t, err)
-	fakeVFs := v1.ConfigMap{
-		ObjectMeta: meta_v1.ObjectMeta{
-			Name:      "faketotalvfs",
-			Namespace: discovery.Namespace,
-		},
-		Data: map[string]string{"sriov_totalvfs": sriovTotalVFs},
-	}
-	_, err = client.ConfigMaps(fakeVFs.Namespace).Create(&fakeVFs)
-	require.NoError(t, err)
 
+	// fake sysfs with configured VFs bound to a kernel driver is created by an init container,
+	// discovery reports only VFs that have virtfn links
 	require.Len(t, discovery.Spec.Template.Spec.Volumes, 1)
 	discovery.Spec.Template.Spec.Volumes[0] = v1.Volume{
-		Name: "sys",
-		VolumeSource: v1.VolumeSource{
-			ConfigMap: &v1.ConfigMapVolumeSource{LocalObjectReference: v1.LocalObjectReference{Name: fakeVFs.Name}},
-		},
+		Name:         "sys",
+		VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
 	}
+	fakeSysfs := fmt.Sprintf(`set -e
+device=/test/sys/class/net/%[1]s/device
+mkdir -p $device /test/sys/bus/pci/drivers/ixgbevf
+echo %[2]s > $device/sriov_totalvfs
+echo %[2]s > $device/sriov_numvfs
+for i in $(seq 0 $((%[2]s - 1))); do
+  mkdir -p $device/virtfn$i
+  ln -s /test/sys/bus/pci/drivers/ixgbevf $device/virtfn$i/driver
+done
+`, vfsDevice, sriovTotalVFs)
+	sysMount := v1.VolumeMount{Name: "sys", MountPath: "/test"}
+	discovery.Spec.Template.Spec.InitContainers = []v1.Container{{
+		Name:         "fake-sysfs",
+		Image:        "busybox",
+		Command:      []string{"sh", "-c", fakeSysfs},
+		VolumeMounts: []v1.VolumeMount{sysMount},
+	}}
 	require.Len(t, discovery.Spec.Template.Spec.Containers, 1)
 	require.Len(t, discovery.Spec.Template.Spec.Containers[0].VolumeMounts, 1)
-	discovery.Spec.Template.Spec.Containers[0].VolumeMounts[0] = v1.VolumeMount{
-		Name:      "sys",
-		MountPath: fmt.Sprintf("/test/sys/class/net/%s/device/", vfsDevice),
-	}
+	discovery.Spec.Template.Spec.Containers[0].VolumeMounts[0] = sysMount
 	discovery.Spec.Template.Spec.Containers[0].Command = append(
 		discovery.Spec.Template.Spec.Containers[0].Command, "--device", vfsDevice, "--directory", "/test")
 	_, err = client.DaemonSets(discovery.Namespace).Create(&discovery)